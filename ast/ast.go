@@ -2,6 +2,8 @@ package ast
 
 import (
 	"strings"
+
+	"github.com/Richtermnd/ferret/token"
 )
 
 type Node interface {
@@ -21,6 +23,9 @@ type Expression interface {
 
 type Program struct {
 	Statements []Statement
+
+	// End is EOF token, it keeps comments after the last statement
+	End token.Token
 }
 
 func (p *Program) Literal() string {
@@ -0,0 +1,68 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Dump return indented tree of node for debugging:
+//
+//	InfixExpression
+//	  Left: IntegerLiteral
+//	    Value: 1
+//	  Operator: "+"
+//	  ...
+//
+// Token and End fields are skipped, other tokens are shown by literal
+func Dump(node Node) string {
+	sb := strings.Builder{}
+	dump(&sb, reflect.ValueOf(node), 0)
+	return sb.String()
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+func dump(sb *strings.Builder, v reflect.Value, level int) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			sb.WriteString("nil\n")
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == tokenType:
+		fmt.Fprintf(sb, "%q\n", v.Interface().(token.Token).Literal)
+		return
+	case v.Kind() == reflect.Slice:
+		sb.WriteString("[\n")
+		for i := range v.Len() {
+			fmt.Fprintf(sb, "%s%d: ", indent(level+1), i)
+			dump(sb, v.Index(i), level+1)
+		}
+		fmt.Fprintf(sb, "%s]\n", indent(level))
+		return
+	case v.Kind() != reflect.Struct:
+		fmt.Fprintf(sb, "%#v\n", v.Interface())
+		return
+	}
+
+	sb.WriteString(v.Type().Name())
+	sb.WriteString("\n")
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Name == "Token" || field.Name == "End" {
+			continue
+		}
+		fmt.Fprintf(sb, "%s%s: ", indent(level+1), field.Name)
+		dump(sb, v.Field(i), level+1)
+	}
+}
+
+func indent(level int) string {
+	return strings.Repeat("  ", level)
+}
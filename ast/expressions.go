@@ -41,6 +41,23 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+type PostfixExpression struct {
+	Token    token.Token
+	Left     Expression
+	Operator string
+}
+
+func (pe *PostfixExpression) exprNode()       {}
+func (pe *PostfixExpression) Literal() string { return pe.Token.Literal }
+func (pe *PostfixExpression) String() string {
+	var out strings.Builder
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+	return out.String()
+}
+
 type InfixExpression struct {
 	Token    token.Token
 	Left     Expression
@@ -61,3 +78,64 @@ func (pe *InfixExpression) String() string {
 	out.WriteString(")")
 	return out.String()
 }
+
+type CallExpression struct {
+	Token     token.Token // '(' or '|>' for pipelines
+	Function  Expression
+	Arguments []Expression
+
+	// Piped is indexes of arguments that came from the left side of pipeline
+	Piped []int
+}
+
+func (ce *CallExpression) exprNode()       {}
+func (ce *CallExpression) Literal() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	var out strings.Builder
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	for i, arg := range ce.Arguments {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(arg.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+// ComparisonChain is a chain of comparisons like 0 < x <= 10,
+// that means 0 < x and x <= 10 with x evaluated once
+type ComparisonChain struct {
+	Token     token.Token // first operator
+	Operands  []Expression
+	Operators []token.Token
+}
+
+func (cc *ComparisonChain) exprNode()       {}
+func (cc *ComparisonChain) Literal() string { return cc.Token.Literal }
+func (cc *ComparisonChain) String() string {
+	var out strings.Builder
+	out.WriteString("(")
+	out.WriteString(cc.Operands[0].String())
+	for i, op := range cc.Operators {
+		out.WriteString(" ")
+		out.WriteString(op.Literal)
+		out.WriteString(" ")
+		out.WriteString(cc.Operands[i+1].String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+// SelectorExpression is name of module member: s.mean.
+// Sel is not a reference to binding, so Inspect doesn't visit it
+type SelectorExpression struct {
+	Token token.Token // '.'
+	X     Expression
+	Sel   *Identifier
+}
+
+func (se *SelectorExpression) exprNode()       {}
+func (se *SelectorExpression) Literal() string { return se.Token.Literal }
+func (se *SelectorExpression) String() string  { return se.X.String() + "." + se.Sel.String() }
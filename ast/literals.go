@@ -1,6 +1,10 @@
 package ast
 
-import "github.com/Richtermnd/ferret/token"
+import (
+	"strings"
+
+	"github.com/Richtermnd/ferret/token"
+)
 
 type FloatLiteral struct {
 	Token token.Token
@@ -29,4 +33,26 @@ func (s *BooleanLiteral) Literal() string { return s.Token.Literal }
 func (s *BooleanLiteral) String() string  { return s.Token.Literal }
 func (s *BooleanLiteral) exprNode()       {}
 
+// VectorLiteral is [1, 2, 3], matrix is a vector of vectors: [[1, 2], [3, 4]]
+type VectorLiteral struct {
+	Token    token.Token // '['
+	Elements []Expression
+	End      token.Token // ']'
+}
+
+func (s *VectorLiteral) Literal() string { return s.Token.Literal }
+func (s *VectorLiteral) String() string {
+	var out strings.Builder
+	out.WriteString("[")
+	for i, el := range s.Elements {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(el.String())
+	}
+	out.WriteString("]")
+	return out.String()
+}
+func (s *VectorLiteral) exprNode() {}
+
 // TODO: string literal
@@ -0,0 +1,68 @@
+package ast
+
+import "github.com/Richtermnd/ferret/token"
+
+// Span return span from the first to the last token of node.
+// Closing parentheses are not kept in ast, so they are not covered
+func Span(node Node) token.Span {
+	var span token.Span
+	Inspect(node, func(n Node) bool {
+		for _, tok := range tokens(n) {
+			if !tok.Pos.IsValid() {
+				continue
+			}
+			s := tok.Span()
+			if !span.Start.IsValid() || s.Start.Less(span.Start) {
+				span.Start = s.Start
+			}
+			if span.End.Less(s.End) {
+				span.End = s.End
+			}
+		}
+		return true
+	})
+	return span
+}
+
+// tokens return tokens of node itself without its children
+func tokens(node Node) []token.Token {
+	switch node := node.(type) {
+	case *Identifier:
+		return []token.Token{node.Token}
+	case *IntegerLiteral:
+		return []token.Token{node.Token}
+	case *FloatLiteral:
+		return []token.Token{node.Token}
+	case *BooleanLiteral:
+		return []token.Token{node.Token}
+	case *VectorLiteral:
+		return []token.Token{node.Token, node.End}
+	case *PrefixExpression:
+		return []token.Token{node.Token}
+	case *PostfixExpression:
+		return []token.Token{node.Token}
+	case *InfixExpression:
+		return []token.Token{node.Token}
+	case *CallExpression:
+		return []token.Token{node.Token}
+	case *SelectorExpression:
+		return []token.Token{node.Token, node.Sel.Token}
+	case *ComparisonChain:
+		return node.Operators
+	case *LetStatement:
+		return []token.Token{node.Token}
+	case *TypeName:
+		return []token.Token{node.Token}
+	case *FunctionStatement:
+		return []token.Token{node.Token}
+	case *ImportStatement:
+		return []token.Token{node.Token, node.Path}
+	case *BlockStatement:
+		return []token.Token{node.Token, node.End}
+	case *PragmaStatement:
+		return []token.Token{node.Token}
+	case *IfStatement:
+		return []token.Token{node.Token}
+	}
+	return nil
+}
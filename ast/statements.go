@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/Richtermnd/ferret/token"
@@ -9,6 +10,9 @@ import (
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+
+	// End is closing brace, it keeps comments after the last statement
+	End token.Token
 }
 
 func (b *BlockStatement) Literal() string { return b.Token.Literal }
@@ -29,16 +33,120 @@ func (b *BlockStatement) stmtNode() {}
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
+	Type  *TypeName // nil without annotation
 	Value Expression
 }
 
 func (ls *LetStatement) Literal() string { return ls.Token.Literal }
-func (ls *LetStatement) String() string  { return "let " + ls.Name.String() + " = " + ls.Value.String() }
-func (ls *LetStatement) stmtNode()       {}
+func (ls *LetStatement) String() string {
+	if ls.Type != nil {
+		return "let " + ls.Name.String() + ": " + ls.Type.String() + " = " + ls.Value.String()
+	}
+	return "let " + ls.Name.String() + " = " + ls.Value.String()
+}
+func (ls *LetStatement) stmtNode() {}
+
+// TypeName is annotation of binding or parameter: float in let rate: float = 0.05.
+// It's not an expression, names of types are not bound in environment
+type TypeName struct {
+	Token token.Token
+	Name  string
+}
+
+func (tn *TypeName) Literal() string { return tn.Token.Literal }
+func (tn *TypeName) String() string  { return tn.Name }
 
+// Parameter of function, Type is nil without annotation
+type Parameter struct {
+	Name *Identifier
+	Type *TypeName
+}
+
+func (p *Parameter) Literal() string { return p.Name.Literal() }
+func (p *Parameter) String() string {
+	if p.Type != nil {
+		return p.Name.String() + ": " + p.Type.String()
+	}
+	return p.Name.String()
+}
+
+// FunctionStatement is fn area(r: float) -> float { ... },
+// result of function is value of the last statement of body
+type FunctionStatement struct {
+	Token      token.Token
+	Name       *Identifier
+	Parameters []*Parameter
+	ReturnType *TypeName // nil without annotation
+	Body       *BlockStatement
+}
+
+func (fs *FunctionStatement) Literal() string { return fs.Token.Literal }
+func (fs *FunctionStatement) String() string {
+	return "fn " + fs.Signature() + " " + fs.Body.String()
+}
+func (fs *FunctionStatement) stmtNode() {}
+
+// Signature return declaration of function without body: area(r: float) -> float
+func (fs *FunctionStatement) Signature() string {
+	sb := strings.Builder{}
+	sb.WriteString(fs.Name.String())
+	sb.WriteString("(")
+	for i, param := range fs.Parameters {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(param.String())
+	}
+	sb.WriteString(")")
+	if fs.ReturnType != nil {
+		sb.WriteString(" -> ")
+		sb.WriteString(fs.ReturnType.String())
+	}
+	return sb.String()
+}
+
+// PragmaStatement is a directive for interpreter like #strict
+type PragmaStatement struct {
+	Token token.Token
+	Name  string
+}
+
+func (ps *PragmaStatement) Literal() string { return ps.Token.Literal }
+func (ps *PragmaStatement) String() string  { return "#" + ps.Name }
+func (ps *PragmaStatement) stmtNode()       {}
+
+// IfStatement is if condition { ... } else { ... }, parser doesn't produce it yet
 type IfStatement struct {
 	Token       token.Token
 	Condition   Expression
 	Consequence *BlockStatement
-	Alternative *BlockStatement
+	Alternative *BlockStatement // nil without else
+}
+
+func (is *IfStatement) Literal() string { return is.Token.Literal }
+func (is *IfStatement) String() string {
+	sb := strings.Builder{}
+	sb.WriteString("if ")
+	sb.WriteString(is.Condition.String())
+	sb.WriteString(" ")
+	sb.WriteString(is.Consequence.String())
+	if is.Alternative != nil {
+		sb.WriteString(" else ")
+		sb.WriteString(is.Alternative.String())
+	}
+	return sb.String()
+}
+func (is *IfStatement) stmtNode() {}
+
+// ImportStatement is import "stats.fe" as s, it's allowed only at top level of file
+type ImportStatement struct {
+	Token token.Token
+	Path  token.Token // string literal, path is relative to importing file
+	Alias *Identifier
+}
+
+func (is *ImportStatement) Literal() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	return "import " + strconv.Quote(is.Path.Literal) + " as " + is.Alias.String()
 }
+func (is *ImportStatement) stmtNode() {}
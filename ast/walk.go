@@ -0,0 +1,92 @@
+package ast
+
+// Inspect traverse ast in depth-first order: it calls f(node),
+// if f returns true Inspect is called for every child of node
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch node := node.(type) {
+	case *Program:
+		for _, stmt := range node.Statements {
+			Inspect(stmt, f)
+		}
+	case *BlockStatement:
+		for _, stmt := range node.Statements {
+			Inspect(stmt, f)
+		}
+	case *LetStatement:
+		Inspect(node.Name, f)
+		if node.Type != nil {
+			Inspect(node.Type, f)
+		}
+		Inspect(node.Value, f)
+	case *FunctionStatement:
+		Inspect(node.Name, f)
+		for _, param := range node.Parameters {
+			Inspect(param, f)
+		}
+		if node.ReturnType != nil {
+			Inspect(node.ReturnType, f)
+		}
+		Inspect(node.Body, f)
+	case *Parameter:
+		Inspect(node.Name, f)
+		if node.Type != nil {
+			Inspect(node.Type, f)
+		}
+	case *ImportStatement:
+		Inspect(node.Alias, f)
+	case *ExpressionStatement:
+		Inspect(node.Expr, f)
+	case *IfStatement:
+		Inspect(node.Condition, f)
+		Inspect(node.Consequence, f)
+		if node.Alternative != nil {
+			Inspect(node.Alternative, f)
+		}
+	case *PrefixExpression:
+		Inspect(node.Right, f)
+	case *PostfixExpression:
+		Inspect(node.Left, f)
+	case *InfixExpression:
+		Inspect(node.Left, f)
+		Inspect(node.Right, f)
+	case *SelectorExpression:
+		Inspect(node.X, f)
+	case *CallExpression:
+		// piped argument is written before function: x |> f,
+		// it's the same node in every placeholder, so it's visited once
+		if node.Token.Literal == "|>" {
+			Inspect(node.Arguments[node.Piped[0]], f)
+			Inspect(node.Function, f)
+			for i, arg := range node.Arguments {
+				if !isPiped(node, i) {
+					Inspect(arg, f)
+				}
+			}
+			return
+		}
+		Inspect(node.Function, f)
+		for _, arg := range node.Arguments {
+			Inspect(arg, f)
+		}
+	case *ComparisonChain:
+		for _, operand := range node.Operands {
+			Inspect(operand, f)
+		}
+	case *VectorLiteral:
+		for _, el := range node.Elements {
+			Inspect(el, f)
+		}
+	}
+}
+
+func isPiped(call *CallExpression, i int) bool {
+	for _, piped := range call.Piped {
+		if piped == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/compiler"
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/format"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/lsp"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/optimizer"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/repl"
+	"github.com/Richtermnd/ferret/token"
+	"github.com/Richtermnd/ferret/types"
+	"github.com/Richtermnd/ferret/vm"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1 // parse or runtime error
+	exitUsage = 2
+)
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ferret %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func strictFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("strict", false, "disable bool as integer arithmetic (same as #strict pragma)")
+}
+
+// vmFlag select bytecode vm instead of tree-walking evaluator
+func vmFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("vm", false, "compile to bytecode and run it in vm")
+}
+
+func runCmd(args []string) int {
+	fs := newFlagSet("run", "[-strict] [-vm] file.fe")
+	strict := strictFlag(fs)
+	useVM := vmFlag(fs)
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	source, err := readSource(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	file := fs.Arg(0)
+	if file == "-" {
+		file = ""
+	}
+	return evalAndPrint(fs.Arg(0), file, source, *strict, *useVM)
+}
+
+func evalCmd(args []string) int {
+	fs := newFlagSet("eval", "[-strict] [-vm] -e expr")
+	strict := strictFlag(fs)
+	useVM := vmFlag(fs)
+	expr := fs.String("e", "", "expression to evaluate")
+	if err := fs.Parse(args); err != nil || *expr == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	return evalAndPrint("-e", "", *expr, *strict, *useVM)
+}
+
+func replCmd(args []string) int {
+	fs := newFlagSet("repl", "[-strict]")
+	strict := strictFlag(fs)
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	env := object.NewEnv()
+	env.SetStrict(*strict)
+	repl.Start(os.Stdin, os.Stdout, env)
+	return exitOK
+}
+
+func checkCmd(args []string) int {
+	fs := newFlagSet("check", "files...")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	code := exitOK
+	for _, name := range fs.Args() {
+		source, err := readSource(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = exitError
+			continue
+		}
+		p := parser.New(lexer.New(source))
+		program := p.Parse()
+		diagnostics := p.Diagnostics()
+		if !p.HasErrors() {
+			diagnostics = append(diagnostics, analyze(program)...)
+		}
+		if diag.HasErrors(diagnostics) {
+			code = exitError
+		}
+		printDiagnostics(name, diagnostics)
+	}
+	return code
+}
+
+// analyze report operations that always fail: impossible types like true * [1, 2]
+// and constant expressions like 1/0. Type error is reported instead of the constant one at the same place
+func analyze(program *ast.Program) []diag.Diagnostic {
+	_, diagnostics := types.Check(program, types.Options{})
+	reported := make(map[token.Span]bool, len(diagnostics))
+	for _, d := range diagnostics {
+		reported[d.Span] = true
+	}
+	_, optimizerDiagnostics := optimizer.Optimize(program, optimizer.Options{})
+	for _, d := range optimizerDiagnostics {
+		if !reported[d.Span] {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Span.Start.Less(diagnostics[j].Span.Start)
+	})
+	return diagnostics
+}
+
+func fmtCmd(args []string) int {
+	fs := newFlagSet("fmt", "[-w] files...")
+	write := fs.Bool("w", false, "write result to file instead of stdout")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	code := exitOK
+	for _, name := range fs.Args() {
+		source, err := readSource(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = exitError
+			continue
+		}
+		formatted, err := format.Source([]byte(source))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			code = exitError
+			continue
+		}
+		if !*write || name == "-" {
+			os.Stdout.Write(formatted)
+			continue
+		}
+		if string(formatted) == source {
+			continue
+		}
+		if err := os.WriteFile(name, formatted, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = exitError
+		}
+	}
+	return code
+}
+
+func lspCmd(args []string) int {
+	fs := newFlagSet("lsp", "")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, "ferret lsp:", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func tokensCmd(args []string) int {
+	fs := newFlagSet("tokens", "file.fe")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	source, err := readSource(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	l := lexer.New(source)
+	for tok := l.NextToken(); !tok.Is(token.EOF); tok = l.NextToken() {
+		fmt.Println(tok)
+	}
+	return exitOK
+}
+
+func astCmd(args []string) int {
+	fs := newFlagSet("ast", "file.fe")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	program, code := parseFile(fs.Arg(0))
+	if program == nil {
+		return code
+	}
+	fmt.Print(ast.Dump(program))
+	return exitOK
+}
+
+// parseFile read and parse file, print errors to stderr
+func parseFile(name string) (*ast.Program, int) {
+	source, err := readSource(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, exitError
+	}
+	return parseSource(name, source)
+}
+
+func parseSource(name, source string) (*ast.Program, int) {
+	p := parser.New(lexer.New(source))
+	program := p.Parse()
+	if p.HasErrors() {
+		printDiagnostics(name, p.Diagnostics())
+		return nil, exitError
+	}
+	return program, exitOK
+}
+
+// printDiagnostics print diagnostics as file:line:col: message
+func printDiagnostics(name string, diagnostics []diag.Diagnostic) {
+	for _, d := range diagnostics {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", name, d)
+	}
+}
+
+// evalAndPrint evaluate source and print the final value,
+// errors go to stderr with non-zero exit code. Imports are relative to file,
+// it's "" for stdin and -e
+func evalAndPrint(name, file, source string, strict, useVM bool) int {
+	program, code := parseSource(name, source)
+	if program == nil {
+		return code
+	}
+	env := object.NewEnv()
+	env.SetStrict(strict)
+	modules := evaluator.NewModules()
+	var evaluated object.Object
+	if useVM {
+		bytecode, err := compiler.Compile(program)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+			return exitError
+		}
+		machine := vm.New(bytecode, env)
+		machine.SetModules(modules, file)
+		if err := machine.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+			return exitError
+		}
+		evaluated = machine.Result()
+	} else {
+		evaluated = modules.EvalContext(context.Background(), env, program, file, evaluator.Limits{})
+	}
+	if evaluated == nil {
+		return exitOK
+	}
+	if object.IsError(evaluated) {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, evaluated.Inspect())
+		return exitError
+	}
+	fmt.Println(evaluated.Inspect())
+	return exitOK
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunExitCode(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected int
+	}{
+		{desc: "value", source: "let a = 1\na + 1", expected: exitOK},
+		{desc: "error in last statement", source: "let a = 1\nx", expected: exitError},
+		{desc: "error in non-final statement", source: "let a = x\n1", expected: exitError},
+		{desc: "error in non-final expression", source: "1 / 0\n1", expected: exitError},
+		{desc: "error in block", source: "{ x\n1 }\n2", expected: exitError},
+		{desc: "failed import", source: "import \"nope.fe\" as n\n1", expected: exitError},
+	}
+	dir := t.TempDir()
+	for _, tt := range testCases {
+		for _, args := range [][]string{nil, {"-vm"}} {
+			t.Run(tt.desc+" "+filepath.Join(args...), func(t *testing.T) {
+				file := filepath.Join(dir, "main.fe")
+				if err := os.WriteFile(file, []byte(tt.source), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if code := runCmd(append(args, file)); code != tt.expected {
+					t.Errorf("expected exit code %d got %d", tt.expected, code)
+				}
+			})
+		}
+	}
+}
+
+func TestFlagsWithoutCommand(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.fe")
+	if err := os.WriteFile(file, []byte("1 + true"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := dispatch([]string{file}); code != exitOK {
+		t.Errorf("expected exit code %d got %d", exitOK, code)
+	}
+	// bool isn't a number in strict mode
+	if code := dispatch([]string{"-strict", file}); code != exitError {
+		t.Errorf("expected exit code %d got %d", exitError, code)
+	}
+}
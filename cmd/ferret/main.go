@@ -1,60 +1,96 @@
 package main
 
 import (
-	"bufio"
-	"flag"
 	"fmt"
+	"io"
 	"os"
-
-	"github.com/Richtermnd/ferret/evaluator"
-	"github.com/Richtermnd/ferret/lexer"
-	"github.com/Richtermnd/ferret/object"
-	"github.com/Richtermnd/ferret/parser"
+	"strings"
 )
 
-func init() {
-	flag.Parse()
+const usage = `ferret is a language for doing math
+
+Usage:
+
+	ferret <command> [arguments]
+
+Commands:
+
+	run     evaluate file and print the final value
+	eval    evaluate expression: ferret eval -e '2 + 2'
+	repl    start interactive session (default without arguments)
+	check   report syntax errors and always failing constant expressions
+	fmt     format files: ferret fmt [-w] files...
+	lsp     start language server on stdin/stdout
+	tokens  print tokens of file
+	ast     print syntax tree of file
+
+Use "-" as a file name to read from stdin.
+Use "ferret <command> -h" for more information about a command.
+`
+
+type command struct {
+	name string
+	run  func(args []string) int
 }
 
-func eval(env *object.Environment, source string) object.Object {
-	l := lexer.New(source)
-	p := parser.New(l)
-	program := p.Parse()
-	if p.HasErrors() {
-		p.PrintErrors(os.Stderr)
-		return nil
-	}
-	return evaluator.Eval(env, program)
+var commands = []command{
+	{name: "run", run: runCmd},
+	{name: "eval", run: evalCmd},
+	{name: "repl", run: replCmd},
+	{name: "check", run: checkCmd},
+	{name: "fmt", run: fmtCmd},
+	{name: "lsp", run: lspCmd},
+	{name: "tokens", run: tokensCmd},
+	{name: "ast", run: astCmd},
 }
 
-func repl() {
-	const prompt = ">> "
-	s := bufio.NewScanner(os.Stdin)
-	env := object.NewEnv()
-	fmt.Print(prompt)
-	for s.Scan() {
-		evaluated := eval(env, s.Text())
-		if evaluated != nil {
-			fmt.Println(evaluated.Inspect())
-		}
-		fmt.Print(prompt)
-	}
+func main() {
+	os.Exit(dispatch(os.Args[1:]))
 }
 
-func fatalf(msg string, args ...any) {
-	fmt.Fprintf(os.Stderr, msg, args...)
-	os.Exit(1)
+func dispatch(args []string) int {
+	if len(args) == 0 {
+		return replCmd(nil)
+	}
+	name := args[0]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args[1:])
+		}
+	}
+	// ferret file.fe is the same as ferret run file.fe
+	if _, err := os.Stat(name); err == nil || name == "-" {
+		return runCmd(args)
+	}
+	// flags without command are from before subcommands: ferret -strict [file.fe]
+	if strings.HasPrefix(name, "-") {
+		if last := args[len(args)-1]; last == "-" || !strings.HasPrefix(last, "-") {
+			return runCmd(args)
+		}
+		return replCmd(args)
+	}
+	fmt.Fprintf(os.Stderr, "ferret: unknown command %q\n\n%s", name, usage)
+	return 2
 }
 
-func main() {
-	if flag.NArg() == 0 {
-		repl()
+// readSource read file, "-" means stdin
+func readSource(name string) (string, error) {
+	var (
+		source []byte
+		err    error
+	)
+	if name == "-" {
+		source, err = io.ReadAll(os.Stdin)
 	} else {
-		source, err := os.ReadFile(flag.Arg(0))
-		if err != nil {
-			fatalf("failed to read %s: %v\n", flag.Arg(0), err)
-		}
-		env := object.NewEnv()
-		eval(env, string(source))
+		source, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
 	}
+	return string(source), nil
 }
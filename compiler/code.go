@@ -0,0 +1,202 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Instructions is bytecode: one byte opcode followed by big endian operands
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	// OpConstant push constant by its index in pool
+	OpConstant Opcode = iota
+	OpTrue
+	OpFalse
+	// OpNull push nil, result of statements without value
+	OpNull
+	// OpPop pop statement result, the last one is the result of program
+	OpPop
+
+	// OpGetName push value of name by its index in names,
+	// builtin with this name or not found error
+	OpGetName
+	// OpSetName pop value and bind it to name, error value isn't bound and becomes the result
+	OpSetName
+	// OpEnterScope and OpLeaveScope wrap block, it has its own environment
+	OpEnterScope
+	OpLeaveScope
+	// OpStrict turn on strict mode in current scope
+	OpStrict
+	// OpAnnotate check value on top of stack against annotation of binding,
+	// operands are indexes of binding name and type name in names
+	OpAnnotate
+	// OpFunction push closure of function constant in current scope
+	OpFunction
+	// OpImport push module, operand is index of its path in names
+	OpImport
+	// OpSelect replace module on top of stack with its member, operand is index of name in names
+	OpSelect
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpRem
+	OpPow
+	OpEqual
+	OpNotEqual
+	OpLess
+	OpLessEqual
+	OpGreater
+	OpGreaterEqual
+	OpAnd
+	OpOr
+
+	OpMinus
+	OpNot
+	OpFactorial
+	OpPercent
+
+	// OpCall call function with arguments on top of stack, operand is number of arguments
+	OpCall
+	// OpDerive push symbolic derivative of d(expr, x) call kept by constant and jumps to operand
+	// if d isn't bound, otherwise the call is compiled after it as any other one
+	OpDerive
+	// OpVector make vector of elements on top of stack, operand is number of elements
+	OpVector
+
+	// OpChain compare two values on top of stack as a part of comparison chain.
+	// If comparison is true the right value is left on stack for the next one,
+	// otherwise false or error is pushed and execution jumps to operand
+	OpChain
+	// OpChainEnd is the last comparison of chain, it push true, false or error
+	OpChainEnd
+)
+
+// Definition describe opcode for disassembling, operands are widths in bytes
+type Definition struct {
+	Name     string
+	Operands []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant:     {"OpConstant", []int{2}},
+	OpTrue:         {"OpTrue", nil},
+	OpFalse:        {"OpFalse", nil},
+	OpNull:         {"OpNull", nil},
+	OpPop:          {"OpPop", nil},
+	OpGetName:      {"OpGetName", []int{2}},
+	OpSetName:      {"OpSetName", []int{2}},
+	OpEnterScope:   {"OpEnterScope", nil},
+	OpLeaveScope:   {"OpLeaveScope", nil},
+	OpStrict:       {"OpStrict", nil},
+	OpAnnotate:     {"OpAnnotate", []int{2, 2}},
+	OpFunction:     {"OpFunction", []int{2}},
+	OpImport:       {"OpImport", []int{2}},
+	OpSelect:       {"OpSelect", []int{2}},
+	OpAdd:          {"OpAdd", nil},
+	OpSub:          {"OpSub", nil},
+	OpMul:          {"OpMul", nil},
+	OpDiv:          {"OpDiv", nil},
+	OpRem:          {"OpRem", nil},
+	OpPow:          {"OpPow", nil},
+	OpEqual:        {"OpEqual", nil},
+	OpNotEqual:     {"OpNotEqual", nil},
+	OpLess:         {"OpLess", nil},
+	OpLessEqual:    {"OpLessEqual", nil},
+	OpGreater:      {"OpGreater", nil},
+	OpGreaterEqual: {"OpGreaterEqual", nil},
+	OpAnd:          {"OpAnd", nil},
+	OpOr:           {"OpOr", nil},
+	OpMinus:        {"OpMinus", nil},
+	OpNot:          {"OpNot", nil},
+	OpFactorial:    {"OpFactorial", nil},
+	OpPercent:      {"OpPercent", nil},
+	OpCall:         {"OpCall", []int{1}},
+	OpDerive:       {"OpDerive", []int{2, 2}},
+	OpVector:       {"OpVector", []int{2}},
+	OpChain:        {"OpChain", []int{1, 2}},
+	OpChainEnd:     {"OpChainEnd", []int{1}},
+}
+
+func Lookup(op Opcode) (*Definition, error) {
+	def, ok := definitions[op]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make encode instruction, missing operands are zero
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+	length := 1
+	for _, w := range def.Operands {
+		length += w
+	}
+	ins := make([]byte, length)
+	ins[0] = byte(op)
+	offset := 1
+	for i, operand := range operands {
+		if i >= len(def.Operands) {
+			break
+		}
+		width := def.Operands[i]
+		switch width {
+		case 1:
+			ins[offset] = byte(operand)
+		case 2:
+			binary.BigEndian.PutUint16(ins[offset:], uint16(operand))
+		}
+		offset += width
+	}
+	return ins
+}
+
+// ReadOperands decode operands of instruction and return how many bytes they take
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.Operands))
+	offset := 0
+	for i, width := range def.Operands {
+		switch width {
+		case 1:
+			operands[i] = int(ins[offset])
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// String disassemble instructions, one per line with offset
+func (ins Instructions) String() string {
+	var sb strings.Builder
+	for i := 0; i < len(ins); {
+		def, err := Lookup(Opcode(ins[i]))
+		if err != nil {
+			fmt.Fprintf(&sb, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&sb, "%04d %s", i, def.Name)
+		for _, operand := range operands {
+			fmt.Fprintf(&sb, " %d", operand)
+		}
+		sb.WriteString("\n")
+		i += 1 + read
+	}
+	return sb.String()
+}
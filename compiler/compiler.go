@@ -0,0 +1,363 @@
+// Package compiler lowers ast to bytecode executed by vm.
+// Names are resolved at run time in environments like in evaluator,
+// so compiled program can be run against different bindings
+package compiler
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Bytecode is compiled program
+type Bytecode struct {
+	Instructions Instructions
+	Constants    []object.Object
+	Names        []string
+}
+
+type Compiler struct {
+	instructions Instructions
+	constants    []object.Object
+	names        []string
+
+	// indexes of already added constants and names
+	constantIndex map[any]int
+	nameIndex     map[string]int
+}
+
+func New() *Compiler {
+	return &Compiler{
+		constantIndex: make(map[any]int),
+		nameIndex:     make(map[string]int),
+	}
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.instructions,
+		Constants:    c.constants,
+		Names:        c.names,
+	}
+}
+
+// Compile is a shortcut for New, Compile and Bytecode
+func Compile(node ast.Node) (*Bytecode, error) {
+	c := New()
+	if err := c.Compile(node); err != nil {
+		return nil, err
+	}
+	return c.Bytecode(), nil
+}
+
+var infixOps = map[token.TokenType]Opcode{
+	token.ADD: OpAdd,
+	token.SUB: OpSub,
+	token.MUL: OpMul,
+	token.DIV: OpDiv,
+	token.REM: OpRem,
+	token.POW: OpPow,
+	token.EQ:  OpEqual,
+	token.NEQ: OpNotEqual,
+	token.LT:  OpLess,
+	token.LEQ: OpLessEqual,
+	token.GT:  OpGreater,
+	token.GEQ: OpGreaterEqual,
+	token.AND: OpAnd,
+	token.OR:  OpOr,
+}
+
+var prefixOps = map[string]Opcode{
+	"-": OpMinus,
+	"!": OpNot,
+}
+
+var postfixOps = map[string]Opcode{
+	"!": OpFactorial,
+	"%": OpPercent,
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.Program:
+		return c.compileStatements(node.Statements)
+
+	case *ast.BlockStatement:
+		c.emit(OpEnterScope)
+		if err := c.compileStatements(node.Statements); err != nil {
+			return err
+		}
+		c.emit(OpLeaveScope)
+
+	case *ast.LetStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		index, err := c.name(node.Name.Value)
+		if err != nil {
+			return err
+		}
+		if node.Type != nil {
+			typ, err := c.name(node.Type.Name)
+			if err != nil {
+				return err
+			}
+			c.emit(OpAnnotate, index, typ)
+		}
+		c.emit(OpSetName, index)
+
+	case *ast.FunctionStatement:
+		// body is compiled with its own constants and names,
+		// so function can be called from any program it gets into
+		body := New()
+		if err := body.compileStatements(node.Body.Statements); err != nil {
+			return err
+		}
+		code := &object.Code{Instructions: body.instructions, Constants: body.constants, Names: body.names}
+		fn, err := c.addConstant(node, &object.Function{Definition: node, Code: code})
+		if err != nil {
+			return err
+		}
+		index, err := c.name(node.Name.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(OpFunction, fn)
+		c.emit(OpSetName, index)
+
+	case *ast.ImportStatement:
+		path, err := c.name(node.Path.Literal)
+		if err != nil {
+			return err
+		}
+		index, err := c.name(node.Alias.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(OpImport, path)
+		c.emit(OpSetName, index)
+
+	case *ast.PragmaStatement:
+		if node.Name == "strict" {
+			c.emit(OpStrict)
+		} else {
+			c.emit(OpNull)
+			c.emit(OpPop)
+		}
+
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expr); err != nil {
+			return err
+		}
+		c.emit(OpPop)
+
+	case *ast.Identifier:
+		index, err := c.name(node.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(OpGetName, index)
+
+	case *ast.IntegerLiteral:
+		return c.constant(node.Value, &object.Integer{Value: node.Value})
+
+	case *ast.FloatLiteral:
+		return c.constant(node.Value, &object.Float{Value: node.Value})
+
+	case *ast.BooleanLiteral:
+		if node.Value {
+			c.emit(OpTrue)
+		} else {
+			c.emit(OpFalse)
+		}
+
+	case *ast.VectorLiteral:
+		if len(node.Elements) > math.MaxUint16 {
+			return fmt.Errorf("too many elements: %d", len(node.Elements))
+		}
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(OpVector, len(node.Elements))
+
+	case *ast.PrefixExpression:
+		op, ok := prefixOps[node.Operator]
+		if !ok {
+			return fmt.Errorf("unknown prefix operator %s", node.Operator)
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+
+	case *ast.PostfixExpression:
+		op, ok := postfixOps[node.Operator]
+		if !ok {
+			return fmt.Errorf("unknown postfix operator %s", node.Operator)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		c.emit(op)
+
+	case *ast.InfixExpression:
+		op, ok := infixOps[node.Token.Type]
+		if !ok {
+			return fmt.Errorf("unknown infix operator %s", node.Operator)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+
+	case *ast.ComparisonChain:
+		return c.compileChain(node)
+
+	case *ast.SelectorExpression:
+		if err := c.Compile(node.X); err != nil {
+			return err
+		}
+		index, err := c.name(node.Sel.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(OpSelect, index)
+
+	case *ast.CallExpression:
+		if len(node.Arguments) > math.MaxUint8 {
+			return fmt.Errorf("too many arguments: %d", len(node.Arguments))
+		}
+		if ident, ok := node.Function.(*ast.Identifier); ok && ident.Value == "d" {
+			return c.compileDerive(node)
+		}
+		return c.compileCall(node)
+
+	default:
+		return fmt.Errorf("can't compile %T", node)
+	}
+	return nil
+}
+
+func (c *Compiler) compileCall(call *ast.CallExpression) error {
+	if err := c.Compile(call.Function); err != nil {
+		return err
+	}
+	for _, arg := range call.Arguments {
+		if err := c.Compile(arg); err != nil {
+			return err
+		}
+	}
+	c.emit(OpCall, len(call.Arguments))
+	return nil
+}
+
+// compileDerive compile d(expr, x) to OpDerive followed by ordinary call,
+// whether d is bound is known only at run time.
+// Symbolic constant keeps the call, derivative is made of its arguments
+func (c *Compiler) compileDerive(call *ast.CallExpression) error {
+	index, err := c.addConstant(call, &object.Symbolic{Expr: call})
+	if err != nil {
+		return err
+	}
+	pos := c.emit(OpDerive, index, 0)
+	if err := c.compileCall(call); err != nil {
+		return err
+	}
+	end := len(c.instructions)
+	if end > math.MaxUint16 {
+		return fmt.Errorf("program is too long")
+	}
+	copy(c.instructions[pos:], Make(OpDerive, index, end))
+	return nil
+}
+
+func (c *Compiler) compileStatements(stmts []ast.Statement) error {
+	for _, stmt := range stmts {
+		if err := c.Compile(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileChain compile every comparison but the last one to OpChain
+// that jumps to the end of chain when it's false
+func (c *Compiler) compileChain(chain *ast.ComparisonChain) error {
+	if err := c.Compile(chain.Operands[0]); err != nil {
+		return err
+	}
+	var jumps []int
+	for i, op := range chain.Operators {
+		if _, ok := infixOps[op.Type]; !ok {
+			return fmt.Errorf("unknown comparison operator %s", op.Literal)
+		}
+		if err := c.Compile(chain.Operands[i+1]); err != nil {
+			return err
+		}
+		if i == len(chain.Operators)-1 {
+			c.emit(OpChainEnd, int(op.Type))
+		} else {
+			jumps = append(jumps, c.emit(OpChain, int(op.Type), 0))
+		}
+	}
+	end := len(c.instructions)
+	if end > math.MaxUint16 {
+		return fmt.Errorf("program is too long")
+	}
+	for _, pos := range jumps {
+		copy(c.instructions[pos:], Make(OpChain, int(c.instructions[pos+1]), end))
+	}
+	return nil
+}
+
+// constant add value to pool once and emit OpConstant
+func (c *Compiler) constant(key any, obj object.Object) error {
+	index, err := c.addConstant(key, obj)
+	if err != nil {
+		return err
+	}
+	c.emit(OpConstant, index)
+	return nil
+}
+
+// addConstant add value to pool once and return its index
+func (c *Compiler) addConstant(key any, obj object.Object) (int, error) {
+	index, ok := c.constantIndex[key]
+	if !ok {
+		index = len(c.constants)
+		if index > math.MaxUint16 {
+			return 0, fmt.Errorf("too many constants")
+		}
+		c.constants = append(c.constants, obj)
+		c.constantIndex[key] = index
+	}
+	return index, nil
+}
+
+func (c *Compiler) name(name string) (int, error) {
+	index, ok := c.nameIndex[name]
+	if !ok {
+		index = len(c.names)
+		if index > math.MaxUint16 {
+			return 0, fmt.Errorf("too many names")
+		}
+		c.names = append(c.names, name)
+		c.nameIndex[name] = index
+	}
+	return index, nil
+}
+
+// emit append instruction and return its position
+func (c *Compiler) emit(op Opcode, operands ...int) int {
+	pos := len(c.instructions)
+	c.instructions = append(c.instructions, Make(op, operands...)...)
+	return pos
+}
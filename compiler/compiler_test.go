@@ -0,0 +1,156 @@
+package compiler_test
+
+import (
+	"testing"
+
+	"github.com/Richtermnd/ferret/compiler"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/token"
+)
+
+func TestMake(t *testing.T) {
+	testCases := []struct {
+		op       compiler.Opcode
+		operands []int
+		expected []byte
+	}{
+		{compiler.OpConstant, []int{65534}, []byte{byte(compiler.OpConstant), 255, 254}},
+		{compiler.OpCall, []int{3}, []byte{byte(compiler.OpCall), 3}},
+		{compiler.OpChain, []int{7, 258}, []byte{byte(compiler.OpChain), 7, 1, 2}},
+		{compiler.OpAdd, nil, []byte{byte(compiler.OpAdd)}},
+	}
+	for _, tt := range testCases {
+		ins := compiler.Make(tt.op, tt.operands...)
+		if string(ins) != string(tt.expected) {
+			t.Errorf("expected %v got %v", tt.expected, ins)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		source    string
+		expected  []compiler.Instructions
+		constants []string
+		names     []string
+	}{
+		{
+			source: "1 + 2 * 1",
+			expected: []compiler.Instructions{
+				compiler.Make(compiler.OpConstant, 0),
+				compiler.Make(compiler.OpConstant, 1),
+				compiler.Make(compiler.OpConstant, 0),
+				compiler.Make(compiler.OpMul),
+				compiler.Make(compiler.OpAdd),
+				compiler.Make(compiler.OpPop),
+			},
+			constants: []string{"1", "2"},
+		},
+		{
+			source: "let a = -5%\n{ #strict\n a! }",
+			expected: []compiler.Instructions{
+				compiler.Make(compiler.OpConstant, 0),
+				compiler.Make(compiler.OpPercent),
+				compiler.Make(compiler.OpMinus),
+				compiler.Make(compiler.OpSetName, 0),
+				compiler.Make(compiler.OpEnterScope),
+				compiler.Make(compiler.OpStrict),
+				compiler.Make(compiler.OpGetName, 0),
+				compiler.Make(compiler.OpFactorial),
+				compiler.Make(compiler.OpPop),
+				compiler.Make(compiler.OpLeaveScope),
+			},
+			constants: []string{"5"},
+			names:     []string{"a"},
+		},
+		{
+			source: "a |> max(1.5, true)",
+			expected: []compiler.Instructions{
+				compiler.Make(compiler.OpGetName, 0),
+				compiler.Make(compiler.OpGetName, 1),
+				compiler.Make(compiler.OpConstant, 0),
+				compiler.Make(compiler.OpTrue),
+				compiler.Make(compiler.OpCall, 3),
+				compiler.Make(compiler.OpPop),
+			},
+			constants: []string{"1.500000"},
+			names:     []string{"max", "a"},
+		},
+		{
+			source: "d(x^2, x)",
+			expected: []compiler.Instructions{
+				compiler.Make(compiler.OpDerive, 0, 20),
+				compiler.Make(compiler.OpGetName, 0),
+				compiler.Make(compiler.OpGetName, 1),
+				compiler.Make(compiler.OpConstant, 1),
+				compiler.Make(compiler.OpPow),
+				compiler.Make(compiler.OpGetName, 1),
+				compiler.Make(compiler.OpCall, 2),
+				compiler.Make(compiler.OpPop),
+			},
+			constants: []string{"d(x ^ 2, x)", "2"},
+			names:     []string{"d", "x"},
+		},
+		{
+			source: "0 < x <= 1",
+			expected: []compiler.Instructions{
+				compiler.Make(compiler.OpConstant, 0),
+				compiler.Make(compiler.OpGetName, 0),
+				compiler.Make(compiler.OpChain, int(token.LT), 15),
+				compiler.Make(compiler.OpConstant, 1),
+				compiler.Make(compiler.OpChainEnd, int(token.LEQ)),
+				compiler.Make(compiler.OpPop),
+			},
+			constants: []string{"0", "1"},
+			names:     []string{"x"},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.source))
+			program := p.Parse()
+			if p.HasErrors() {
+				t.Fatal(p.Errors())
+			}
+			bytecode, err := compiler.Compile(program)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expected compiler.Instructions
+			for _, ins := range tt.expected {
+				expected = append(expected, ins...)
+			}
+			if bytecode.Instructions.String() != expected.String() {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, bytecode.Instructions)
+			}
+			if len(bytecode.Constants) != len(tt.constants) {
+				t.Fatalf("expected %d constants got %d", len(tt.constants), len(bytecode.Constants))
+			}
+			for i, c := range bytecode.Constants {
+				if c.Inspect() != tt.constants[i] {
+					t.Errorf("constant %d: expected %s got %s", i, tt.constants[i], c.Inspect())
+				}
+			}
+			if len(bytecode.Names) != len(tt.names) {
+				t.Fatalf("expected names %v got %v", tt.names, bytecode.Names)
+			}
+			for i, name := range bytecode.Names {
+				if name != tt.names[i] {
+					t.Errorf("expected names %v got %v", tt.names, bytecode.Names)
+				}
+			}
+		})
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	ins := compiler.Instructions{}
+	ins = append(ins, compiler.Make(compiler.OpConstant, 1)...)
+	ins = append(ins, compiler.Make(compiler.OpCall, 2)...)
+	ins = append(ins, compiler.Make(compiler.OpPop)...)
+	expected := "0000 OpConstant 1\n0003 OpCall 2\n0005 OpPop\n"
+	if ins.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, ins)
+	}
+}
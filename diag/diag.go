@@ -0,0 +1,86 @@
+// Package diag defines diagnostics: errors and warnings about source code
+// with position of the problem and optional hint how to fix it
+package diag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Richtermnd/ferret/token"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Info
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Info:
+		return "info"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Span     token.Span
+	Message  string
+	Hint     string // optional
+}
+
+// Errorf make error diagnostic
+func Errorf(span token.Span, format string, args ...any) Diagnostic {
+	return Diagnostic{Severity: Error, Span: span, Message: fmt.Sprintf(format, args...)}
+}
+
+// Warningf make warning diagnostic
+func Warningf(span token.Span, format string, args ...any) Diagnostic {
+	return Diagnostic{Severity: Warning, Span: span, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error return "line:col: message", warnings and infos are prefixed with severity
+func (d Diagnostic) Error() string {
+	sb := strings.Builder{}
+	if d.Span.Start.IsValid() {
+		sb.WriteString(d.Span.Start.String())
+		sb.WriteString(": ")
+	}
+	if d.Severity != Error {
+		sb.WriteString(d.Severity.String())
+		sb.WriteString(": ")
+	}
+	sb.WriteString(d.Message)
+	return sb.String()
+}
+
+// String return Error() with hint on the next line
+func (d Diagnostic) String() string {
+	if d.Hint == "" {
+		return d.Error()
+	}
+	return d.Error() + "\n\thint: " + d.Hint
+}
+
+// WithHint return copy of diagnostic with hint
+func (d Diagnostic) WithHint(format string, args ...any) Diagnostic {
+	d.Hint = fmt.Sprintf(format, args...)
+	return d
+}
+
+// HasErrors report if there is at least one diagnostic with Error severity
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,10 @@
 package evaluator
 
 import (
+	"context"
+	"math"
+	"math/big"
+
 	"github.com/Richtermnd/ferret/ast"
 	"github.com/Richtermnd/ferret/object"
 	"github.com/Richtermnd/ferret/token"
@@ -11,26 +15,65 @@ var (
 	FALSE = &object.Bool{Value: false}
 )
 
+// Eval evaluate node without limits
 func Eval(env *object.Environment, node ast.Node) object.Object {
+	return EvalContext(context.Background(), env, node, Limits{})
+}
+
+// EvalContext evaluate node until ctx is done or limits are exceeded,
+// then the result is error with LIMIT_ERR type.
+// Names are bound in sub environment of frozen env and discarded after evaluation
+func EvalContext(ctx context.Context, env *object.Environment, node ast.Node, limits Limits) object.Object {
+	s := &state{done: ctx.Done(), ctx: ctx, limits: limits}
+	return s.eval(writable(env), node)
+}
+
+// writable return env or its sub environment if env is frozen
+func writable(env *object.Environment) *object.Environment {
+	if env.Frozen() {
+		return env.SubEnv()
+	}
+	return env
+}
+
+func (s *state) eval(env *object.Environment, node ast.Node) object.Object {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	res := s.evalNode(env, node)
+	s.depth--
+	return res
+}
+
+func (s *state) evalNode(env *object.Environment, node ast.Node) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalStatements(env, node.Statements)
+		return s.evalStatements(env, node.Statements)
 
 	case *ast.Identifier:
 		return evalIdentifier(env, node)
 
 	case *ast.BlockStatement:
-		return evalStatements(env.SubEnv(), node.Statements)
+		return s.evalStatements(env.SubEnv(), node.Statements)
 
 	case *ast.LetStatement:
-		value := Eval(env, node.Value)
+		value := s.eval(env, node.Value)
+		if node.Type != nil {
+			value = Annotate(node.Name.Value, node.Type.Name, value)
+		}
 		if object.IsError(value) {
 			return value
 		}
 		env.Set(node.Name.Value, value)
 
+	case *ast.FunctionStatement:
+		declare(env, node)
+
+	case *ast.ImportStatement:
+		return s.evalImport(env, node)
+
 	case *ast.ExpressionStatement:
-		return Eval(env, node.Expr)
+		return s.eval(env, node.Expr)
 
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
@@ -41,33 +84,103 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 	case *ast.BooleanLiteral:
 		return boolFromNative(node.Value)
 
+	case *ast.VectorLiteral:
+		elements := s.evalExpressions(env, node.Elements)
+		if len(elements) == 1 && object.IsError(elements[0]) {
+			return elements[0]
+		}
+		return s.checkAlloc(&object.Vector{Elements: elements})
+
+	case *ast.PragmaStatement:
+		if node.Name == "strict" {
+			env.SetStrict(true)
+		}
+
 	case *ast.PrefixExpression:
-		right := Eval(env, node.Right)
-		return evalPrefixExpression(node.Operator, right)
+		return s.checkLimit(PrefixLimits(node.Operator, s.eval(env, node.Right), env.Strict(), s.limits))
+
+	case *ast.PostfixExpression:
+		return s.checkLimit(PostfixContext(s.ctx, node.Operator, s.eval(env, node.Left), env.Strict(), s.limits))
 
 	case *ast.InfixExpression:
-		left := Eval(env, node.Left)
-		right := Eval(env, node.Right)
-		return evalInfixExpression(node.Token, left, right)
+		left := s.eval(env, node.Left)
+		if object.IsError(left) {
+			return left
+		}
+		return s.checkLimit(InfixLimits(node.Token, left, s.eval(env, node.Right), env.Strict(), s.limits))
+
+	case *ast.ComparisonChain:
+		return s.evalComparisonChain(env, node)
+
+	case *ast.SelectorExpression:
+		return Select(s.eval(env, node.X), node.Sel.Value)
+
+	case *ast.CallExpression:
+		if IsDerive(env, node) {
+			return Derive(env, node)
+		}
+		function := s.eval(env, node.Function)
+		if object.IsError(function) {
+			return function
+		}
+		args := s.evalExpressions(env, node.Arguments)
+		return s.checkAlloc(s.call(function, args, env.Strict()))
 	}
 
 	return nil
 }
 
-func evalStatements(env *object.Environment, stmts []ast.Statement) object.Object {
+// evalStatements return result of the last statement or the first error
+func (s *state) evalStatements(env *object.Environment, stmts []ast.Statement) object.Object {
 	var res object.Object
 	for _, stmt := range stmts {
-		res = Eval(env, stmt)
+		res = s.eval(env, stmt)
+		if s.err != nil {
+			return s.err
+		}
+		if res != nil && object.IsError(res) {
+			return res
+		}
 	}
 	return res
 }
 
 func evalIdentifier(env *object.Environment, node *ast.Identifier) object.Object {
-	obj, ok := env.Get(node.Value)
-	if !ok {
-		return object.NewError(object.NOT_FOUND_ERR, "%s", node.Value)
+	if obj, ok := env.Get(node.Value); ok {
+		return obj
+	}
+	if builtin, ok := object.LookupBuiltin(node.Value); ok {
+		return builtin
+	}
+	return object.NewError(object.NOT_FOUND_ERR, "%s", node.Value)
+}
+
+// evalExpressions evaluate expressions one by one,
+// on error return slice with only this error
+func (s *state) evalExpressions(env *object.Environment, exprs []ast.Expression) []object.Object {
+	res := make([]object.Object, 0, len(exprs))
+	for _, expr := range exprs {
+		evaluated := s.eval(env, expr)
+		if object.IsError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		res = append(res, evaluated)
 	}
-	return obj
+	return res
+}
+
+func applyFunction(function object.Object, args []object.Object) object.Object {
+	switch function := function.(type) {
+	case *object.Builtin:
+		return function.Fn(args...)
+	case *object.Function:
+		res, _ := Apply(context.Background(), function, args, Limits{}, 0, 0)
+		return res
+	case *object.Symbolic:
+		res, _ := Substitute(context.Background(), function, args, Limits{}, 0, 0)
+		return res
+	}
+	return object.NewError(object.NOT_CALLABLE_ERR, "%s", function.Type())
 }
 
 func evalPrefixExpression(op string, right object.Object) object.Object {
@@ -86,10 +199,104 @@ func evalMinusPrefixOperator(right object.Object) object.Object {
 		return &object.Integer{Value: -right.(*object.Integer).Value}
 	case object.FLOAT_OBJ:
 		return &object.Float{Value: -right.(*object.Float).Value}
+	case object.BIGINT_OBJ:
+		return right.(*object.BigInt).Neg()
+	case object.DUAL_OBJ:
+		d := right.(*object.Dual)
+		return &object.Dual{Real: -d.Real, Eps: -d.Eps}
+	case object.VECTOR_OBJ:
+		elements := make([]object.Object, len(right.(*object.Vector).Elements))
+		for i, el := range right.(*object.Vector).Elements {
+			elements[i] = evalMinusPrefixOperator(el)
+			if object.IsError(elements[i]) {
+				return elements[i]
+			}
+		}
+		return &object.Vector{Elements: elements}
 	}
 	return object.NewError(object.UNSUPPORTED_ERR, "-%s", right.Type())
 }
 
+func evalPostfixExpression(ctx context.Context, op string, left object.Object, limits Limits) object.Object {
+	switch op {
+	case "!":
+		return factorial(ctx, left, limits)
+	case "%":
+		return percent(left)
+	}
+	return object.NewError(object.UNKNOWN_OPERATOR_ERR, "%s%s", left.Type(), op)
+}
+
+// factorial is exact for integers (BigInt when result doesn't fit in int64)
+// and n! = Γ(n + 1) for floats
+func factorial(ctx context.Context, v object.Object, limits Limits) object.Object {
+	switch v := v.(type) {
+	case *object.Integer:
+		if v.Value < 0 {
+			return object.NewError(object.UNSUPPORTED_ERR, "factorial of negative integer %d", v.Value)
+		}
+		// log2(n!) estimates size of result without computing it
+		lg, _ := math.Lgamma(float64(v.Value) + 1)
+		if n := words(int(lg / math.Ln2)); limits.MaxAlloc > 0 && n > limits.MaxAlloc {
+			return object.NewError(object.LIMIT_ERR, "%d! is about %d words, maximum is %d", v.Value, n, limits.MaxAlloc)
+		}
+		res, err := mulRange(ctx, 1, v.Value)
+		if err != nil {
+			return object.NewError(object.LIMIT_ERR, "%v", err)
+		}
+		return object.NewBigInt(res)
+	case *object.Float:
+		// Γ has poles in non-positive integers
+		if v.Value < 0 && v.Value == math.Trunc(v.Value) {
+			return object.NewError(object.UNSUPPORTED_ERR, "factorial of negative integer %s", v.Inspect())
+		}
+		return &object.Float{Value: math.Gamma(v.Value + 1)}
+	case *object.Bool:
+		return factorial(ctx, v.AsInt(), limits)
+	}
+	return object.NewError(object.UNSUPPORTED_ERR, "%s!", v.Type())
+}
+
+// factorialChunk is number of factors multiplied between checks of ctx
+const factorialChunk = 1024
+
+// mulRange is big.Int.MulRange that stops when ctx is done,
+// halves are multiplied to keep operands of similar size
+func mulRange(ctx context.Context, a, b int64) (*big.Int, error) {
+	if b-a < factorialChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return new(big.Int).MulRange(a, b), nil
+	}
+	m := a + (b-a)/2
+	l, err := mulRange(ctx, a, m)
+	if err != nil {
+		return nil, err
+	}
+	r, err := mulRange(ctx, m+1, b)
+	if err != nil {
+		return nil, err
+	}
+	return l.Mul(l, r), nil
+}
+
+func percent(v object.Object) object.Object {
+	switch v := v.(type) {
+	case *object.Integer:
+		return &object.Float{Value: float64(v.Value) / 100}
+	case *object.Float:
+		return &object.Float{Value: v.Value / 100}
+	case *object.BigInt:
+		return &object.Float{Value: v.AsFloat().Value / 100}
+	case *object.Dual:
+		return &object.Dual{Real: v.Real / 100, Eps: v.Eps / 100}
+	case *object.Bool:
+		return percent(v.AsFloat())
+	}
+	return object.NewError(object.UNSUPPORTED_ERR, "%s%%", v.Type())
+}
+
 // TODO: split this func in smaller pieces
 func evalInfixExpression(tok token.Token, left, right object.Object) object.Object {
 	if tok.Type == token.AND || tok.Type == token.OR {
@@ -104,6 +311,10 @@ func evalInfixExpression(tok token.Token, left, right object.Object) object.Obje
 		return mul(left, right)
 	case token.DIV:
 		return div(left, right)
+	case token.REM:
+		return rem(left, right)
+	case token.POW:
+		return power(left, right)
 	}
 
 	leftCmp, lok := left.(object.Compared)
@@ -128,6 +339,27 @@ func evalInfixExpression(tok token.Token, left, right object.Object) object.Obje
 	return object.NewError(object.UNKNOWN_OPERATOR_ERR, "%s %s %s", left.Type(), tok.String(), right.Type())
 }
 
+// evalComparisonChain evaluate comparisons pairwise,
+// every operand evaluated at most once, stop on first false
+func (s *state) evalComparisonChain(env *object.Environment, chain *ast.ComparisonChain) object.Object {
+	left := s.eval(env, chain.Operands[0])
+	if object.IsError(left) {
+		return left
+	}
+	for i, op := range chain.Operators {
+		right := s.eval(env, chain.Operands[i+1])
+		res := Infix(op, left, right, env.Strict())
+		if object.IsError(res) {
+			return res
+		}
+		if !IsTrue(res) {
+			return FALSE
+		}
+		left = right
+	}
+	return TRUE
+}
+
 func evalLogicExpression(tok token.Token, left, right object.Object) object.Object {
 	var a, b bool
 
@@ -208,7 +440,8 @@ func div(left, right object.Object) object.Object {
 	leftDiver, ok := left.(object.Diver)
 	if ok {
 		res = leftDiver.Div(right)
-		if !object.IsError(res) {
+		// division by zero is the answer, not a missing implementation
+		if err, ok := res.(*object.Error); !ok || err.ErrType == object.ZERO_DIVISION_ERR {
 			return res
 		}
 	}
@@ -220,6 +453,56 @@ func div(left, right object.Object) object.Object {
 	return rightDiver.Rdiv(left)
 }
 
+// rem is remainder of truncated division like in Go: -7 % 2 is -1,
+// it's exact for integers and math.Mod for floats
+func rem(left, right object.Object) object.Object {
+	if l, ok := left.(*object.Bool); ok {
+		left = l.AsInt()
+	}
+	if r, ok := right.(*object.Bool); ok {
+		right = r.AsInt()
+	}
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok && r.Value != 0 {
+			return &object.Integer{Value: l.Value % r.Value}
+		}
+	}
+	l, lInt := asBig(left)
+	r, rInt := asBig(right)
+	if lInt && rInt {
+		if r.Sign() == 0 {
+			return object.NewError(object.ZERO_DIVISION_ERR, "%s %% 0", left.Inspect())
+		}
+		return object.NewBigInt(new(big.Int).Rem(l, r))
+	}
+	_, lFloat := left.(*object.Float)
+	_, rFloat := right.(*object.Float)
+	if (lInt || lFloat) && (rInt || rFloat) {
+		a, _ := object.AsNative(left)
+		b, _ := object.AsNative(right)
+		return &object.Float{Value: math.Mod(a, b)}
+	}
+	return object.NewError(object.UNSUPPORTED_ERR, "%s %% %s", left.Type(), right.Type())
+}
+
+// asBig represent Integer and BigInt as big.Int
+func asBig(obj object.Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value), true
+	case *object.BigInt:
+		return obj.Value, true
+	}
+	return nil, false
+}
+
+func power(left, right object.Object) object.Object {
+	if res, ok := object.Power(left, right); ok {
+		return res
+	}
+	return object.NewError(object.UNSUPPORTED_ERR, "%s ^ %s", left.Type(), right.Type())
+}
+
 func not(v object.Object) object.Object {
 	if v.Type() == object.BOOL_OBJ {
 		return &object.Bool{Value: !v.(*object.Bool).Value}
@@ -1,13 +1,22 @@
 package evaluator_test
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/compiler"
 	"github.com/Richtermnd/ferret/evaluator"
 	"github.com/Richtermnd/ferret/lexer"
 	"github.com/Richtermnd/ferret/object"
 	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/vm"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -56,6 +65,16 @@ func TestEvalIntegerExpression(t *testing.T) {
 			source:   "35 + (20 - 3) * 2",
 			expected: 69,
 		},
+		{
+			desc:     "implicit multiplication",
+			source:   "let x = 17; 2x + 35",
+			expected: 69,
+		},
+		{
+			desc:     "implicit multiplication with paranthesis",
+			source:   "3(20 + 3)",
+			expected: 69,
+		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -166,7 +185,14 @@ func TestEvalBoolExpression(t *testing.T) {
     true 1.0 > false
     true 1.0 >= false
     false 1.0 < false
-    false 1.0 <= false`
+    false 1.0 <= false
+
+    true 0 < 5 <= 10
+    false 0 < 15 <= 10
+    false 0 < -5 <= 10
+    true 3 > 2 > 1
+    false 1 == 1 != 1
+    true 1 < 2 == 2.0 >= 2`
 	for _, line := range strings.Split(source, "\n") {
 		t.Run(line, func(t *testing.T) {
 			if line == "" {
@@ -219,6 +245,792 @@ func TestLetStatement(t *testing.T) {
 	}
 }
 
+func TestCallExpression(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected float64
+	}{
+		{
+			desc:     "builtin",
+			source:   "sqrt(16)",
+			expected: 4,
+		},
+		{
+			desc:     "nested",
+			source:   "sqrt(abs(-16.0))",
+			expected: 4,
+		},
+		{
+			desc:     "pipe",
+			source:   "-16.0 |> abs |> sqrt",
+			expected: 4,
+		},
+		{
+			desc:     "pipe placeholder",
+			source:   "let x = 2.0; 3 |> pow(x, _)",
+			expected: 8,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			testFloatObject(t, testEval(t, tt.source), tt.expected)
+		})
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		source string
+	}{
+		{
+			desc:   "not callable",
+			source: "let a = 1; a(2)",
+		},
+		{
+			desc:   "wrong number of arguments",
+			source: "sqrt(1, 2)",
+		},
+		{
+			desc:   "unknown function",
+			source: "foo(1)",
+		},
+		{
+			desc:   "integer division by zero",
+			source: "let a = 0; 1 / a",
+		},
+		{
+			desc:   "big integer division by zero",
+			source: "25! / (1 - 1)",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			res := testEval(t, tt.source)
+			if !object.IsError(res) {
+				t.Errorf("expected error got: %s", res.Inspect())
+			}
+		})
+	}
+}
+
+func TestPostfixExpression(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "factorial",
+			source:   "5!",
+			expected: "120",
+		},
+		{
+			desc:     "zero factorial",
+			source:   "0!",
+			expected: "1",
+		},
+		{
+			desc:     "big factorial",
+			source:   "25!",
+			expected: "15511210043330985984000000",
+		},
+		{
+			desc:     "big factorial back to int",
+			source:   "25! / 24!",
+			expected: "25",
+		},
+		{
+			desc:     "float factorial",
+			source:   "3.0!",
+			expected: "6.000000",
+		},
+		{
+			desc:     "percent",
+			source:   "200 * 15%",
+			expected: "30.000000",
+		},
+		{
+			desc:     "percent binds tighter than division",
+			source:   "100 / 50%",
+			expected: "200.000000",
+		},
+		{
+			desc:     "negative big factorial",
+			source:   "abs(-(25!)) == 25!",
+			expected: "true",
+		},
+		{
+			desc:     "big factorial in builtins",
+			source:   "[sqrt(25!) > 3.9e12, max(1, 25!) == 25!]",
+			expected: "[true, true]",
+		},
+		{
+			desc:     "big factorial power",
+			source:   "(25!)^2 > 2.4e50",
+			expected: "true",
+		},
+		{
+			desc:     "negative factorial",
+			source:   "(-1)!",
+			expected: "[ERROR] unsupported: factorial of negative integer -1",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestVectorExpression(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "literal",
+			source:   "let a = 2; [1, a, a + 1.5]",
+			expected: "[1, 2, 3.500000]",
+		},
+		{
+			desc:     "add",
+			source:   "[1, 2] + [3, 4] - [1, 1]",
+			expected: "[3, 5]",
+		},
+		{
+			desc:     "scale",
+			source:   "2 * [1, 2] / 2.0",
+			expected: "[1.000000, 2.000000]",
+		},
+		{
+			desc:     "negate",
+			source:   "-[[1, 2], [3, 4]]",
+			expected: "[[-1, -2], [-3, -4]]",
+		},
+		{
+			desc:     "dot product",
+			source:   "[1, 2, 3] * [4, 5, 6]",
+			expected: "32",
+		},
+		{
+			desc:     "matrix by vector",
+			source:   "[[1, 2], [3, 4], [5, 6]] * [1, 1]",
+			expected: "[3, 7, 11]",
+		},
+		{
+			desc:     "vector by matrix",
+			source:   "[1, 1, 1] * [[1, 2], [3, 4], [5, 6]]",
+			expected: "[9, 12]",
+		},
+		{
+			desc:     "matrix by matrix",
+			source:   "[[1, 2], [3, 4]] * [[0, 1], [1, 0]]",
+			expected: "[[2, 1], [4, 3]]",
+		},
+		{
+			desc:     "bool is not a scale",
+			source:   "true * [1, 2]",
+			expected: "[ERROR] unsupported: BOOL * VECTOR",
+		},
+		{
+			desc:     "length mismatch",
+			source:   "[1, 2] + [1, 2, 3]",
+			expected: "[ERROR] shape mismatch: 2 + 3",
+		},
+		{
+			desc:     "product mismatch",
+			source:   "[[1, 2, 3], [4, 5, 6]] * [[1, 2], [3, 4]]",
+			expected: "[ERROR] shape mismatch: 2x3 * 2x2",
+		},
+		{
+			desc:     "vector plus number",
+			source:   "[1, 2] + 1",
+			expected: "[ERROR] unsupported: VECTOR + INTEGER",
+		},
+		{
+			desc:     "error element",
+			source:   "[1, 1 / 0]",
+			expected: "[ERROR] division by zero: 1 / 0",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "float",
+			source:   "let rate: float = 0.05; rate",
+			expected: "0.050000",
+		},
+		{
+			desc:     "int is widened to float",
+			source:   "let rate: float = 1; rate",
+			expected: "1.000000",
+		},
+		{
+			desc:     "big int is an int",
+			source:   "let n: int = 25!; n > 0",
+			expected: "true",
+		},
+		{
+			desc:     "vector",
+			source:   "let v: vector = [1, 2]; v",
+			expected: "[1, 2]",
+		},
+		{
+			desc:     "float is not int",
+			source:   "let n: int = 2.5",
+			expected: "[ERROR] type error: n: expected int got FLOAT",
+		},
+		{
+			desc:     "bool is not a number",
+			source:   "let x: float = 1 > 0",
+			expected: "[ERROR] type error: x: expected float got BOOL",
+		},
+		{
+			desc:     "mismatch stops program",
+			source:   "let a = 1; let a: bool = 2; a",
+			expected: "[ERROR] type error: a: expected bool got INTEGER",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "call",
+			source:   "fn area(r: float) -> float { 3 * r * r }; area(2)",
+			expected: "12.000000",
+		},
+		{
+			desc:     "no annotations",
+			source:   "fn add(a, b) { a + b }; add(1, 2) |> add(_, [3])",
+			expected: "[ERROR] unsupported: INTEGER + VECTOR",
+		},
+		{
+			desc:     "closure",
+			source:   "let k = 2\nfn scale(x) { k * x }\nlet k = 3\n{ let k = 10; scale(1) }",
+			expected: "3",
+		},
+		{
+			desc:     "locals",
+			source:   "let y = 1\nfn f(x) {\n let y = x * 2\n y + 1\n}\nf(5) + y",
+			expected: "12",
+		},
+		{
+			desc:     "builtin shadowing",
+			source:   "fn sqrt(x) { x }; sqrt(4)",
+			expected: "4",
+		},
+		{
+			desc:     "argument type",
+			source:   "fn area(r: float) -> float { r }; area(true)",
+			expected: "[ERROR] type error: area: argument r: expected float got BOOL",
+		},
+		{
+			desc:     "result type",
+			source:   "fn half(x) -> int { x / 2.0 }; half(3)",
+			expected: "[ERROR] type error: half: result: expected int got FLOAT",
+		},
+		{
+			desc:     "arguments count",
+			source:   "fn f(a, b) { a }; f(1)",
+			expected: "[ERROR] wrong arguments: f: expected 2 arguments got 1",
+		},
+		{
+			desc:     "no result",
+			source:   "fn f() { let a = 1 }; f()",
+			expected: "[ERROR] type error: f: no result, the last statement of body is not an expression",
+		},
+		{
+			desc:     "recursion",
+			source:   "fn f(x) { f(x) }; f(1)",
+			expected: "[ERROR] recursion too deep: f: more than 1000 nested calls",
+		},
+		{
+			desc:     "strict body",
+			source:   "#strict\nfn f(x) { x + 1 }; f(true)",
+			expected: "[ERROR] type error: BOOL + INTEGER: bool is not a number",
+		},
+		{
+			desc:     "inspect",
+			source:   "fn area(r: float, n) -> float { r }; area",
+			expected: "fn area(r: float, n) -> float",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestPower(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"2^10", "1024"},
+		{"2^3^2", "512"},
+		{"-2^2", "-4"},
+		{"2^-1", "0.500000"},
+		{"3^35", "50031545098999707"},
+		{"pow(3, 35)", "50031545098999707"},
+		{"(-2)^63", "-9223372036854775808"},
+		{"2^64", "18446744073709551616"},
+		{"(-3)^41", "-36472996377170786403"},
+		{"(2^64)^2", "340282366920938463463374607431768211456"},
+		{"4^0.5", "2.000000"},
+		{"let x = 3; 2x^2", "18"},
+		{"2^true", "2.000000"},
+		{"#strict\n2^true", "[ERROR] type error: INTEGER ^ BOOL: bool is not a number"},
+		{"[1, 2]^2", "[ERROR] unsupported: VECTOR ^ INTEGER"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestRemainder(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"7 % 2", "1"},
+		{"(-7) % 2", "-1"},
+		{"7 % (-2)", "1"},
+		{"7.5 % 2", "1.500000"},
+		{"7 % 2.5", "2.000000"},
+		{"(25!) % 7", "0"},
+		{"(25! + 3) % (21!)", "3"},
+		{"true % 2", "1"},
+		{"7 % 0", "[ERROR] division by zero: 7 % 0"},
+		{"#strict\n7 % true", "[ERROR] type error: INTEGER % BOOL: bool is not a number"},
+		{"[1, 2] % 2", "[ERROR] unsupported: VECTOR % INTEGER"},
+		{"50% - 10", "-9.500000"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestDerivatives(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "product",
+			source:   "d(x^2 * sin(x), x)",
+			expected: "2 * x * sin(x) + x ^ 2 * cos(x)",
+		},
+		{
+			desc:     "call",
+			source:   "let f = d(x^3, x); f(2)",
+			expected: "12",
+		},
+		{
+			desc:     "no integer division",
+			source:   "d(1 / x, x)(2)",
+			expected: "-0.250000",
+		},
+		{
+			desc:     "constants",
+			source:   "let a = 2; let f = d(a * x^2, x); let a = 3; f(1)",
+			expected: "6",
+		},
+		{
+			desc:     "second derivative",
+			source:   "let f = d(x^3, x); d(f, x)",
+			expected: "6 * x",
+		},
+		{
+			desc:     "call of derivative",
+			source:   "let f = d(sin(x), x); d(f(x^2), x)",
+			expected: "-2 * sin(x ^ 2) * x",
+		},
+		{
+			desc:     "bound d",
+			source:   "fn d(a, b) { a + b }; d(1, 2)",
+			expected: "3",
+		},
+		{
+			desc:     "shadowed builtin",
+			source:   "fn sin(x) { x }; d(sin(x), x)",
+			expected: "[ERROR] unsupported: d: can't differentiate sin(x): derivative of sin is unknown",
+		},
+		{
+			desc:     "unknown function",
+			source:   "d(max(x, 2), x)",
+			expected: "[ERROR] unsupported: d: can't differentiate max(x, 2): derivative of max is unknown",
+		},
+		{
+			desc:     "factorial",
+			source:   "d(x!, x)",
+			expected: "[ERROR] unsupported: d: can't differentiate x!",
+		},
+		{
+			desc:     "variable",
+			source:   "d(x^2, 2)",
+			expected: "[ERROR] type error: d: expected name of variable got 2",
+		},
+		{
+			desc:     "arguments count",
+			source:   "d(x^2, x)(1, 2)",
+			expected: "[ERROR] wrong arguments: 2 * x: expected 1 arguments got 2",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestGrad(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "partial derivatives",
+			source:   "fn f(x, y) { x^2 * y + sin(y) }; grad(f, [3, 0])",
+			expected: "[0.000000, 10.000000]",
+		},
+		{
+			desc:     "arithmetic",
+			source:   "fn f(x, y) { (x - y) / (x * y) + 1 / x - y / 2 + -x }; grad(f, [1, 2])",
+			expected: "[-1.000000, -0.750000]",
+		},
+		{
+			desc:     "builtins",
+			source:   "fn f(x) { exp(x) * ln(x) + sqrt(x) * cos(x) + atan(x) + abs(-x) + floor(x) }; grad(f, [1])",
+			expected: "[3.646962]",
+		},
+		{
+			desc:     "power",
+			source:   "fn f(x, y) { x^y + pow(2, x) + (-x)^2 }; grad(f, [1, 3])",
+			expected: "[6.386294, 0.000000]",
+		},
+		{
+			desc:     "zero power at zero",
+			source:   "fn f(x) { x^0 + x^1 + pow(x, 0) }; grad(f, [0])",
+			expected: "[1.000000]",
+		},
+		{
+			desc:     "vectors of duals",
+			source:   "fn f(x) { [x, 1] * 2x * [1, x] }; grad(f, [3])",
+			expected: "[24.000000]",
+		},
+		{
+			desc:     "big integers",
+			source:   "fn f(x) { x * 25! / 25! + max(x, 25!) - min(x, 25!) + (x < 25!) }; grad(f, [1])",
+			expected: "[0.000000]",
+		},
+		{
+			desc:     "comparisons on real part",
+			source:   "fn relu(x) { max(0, x) * (x > 0) }; [grad(relu, [2]), grad(relu, [-2])]",
+			expected: "[[1.000000], [0.000000]]",
+		},
+		{
+			desc:     "builtin",
+			source:   "grad(sin, [0])",
+			expected: "[1.000000]",
+		},
+		{
+			desc:     "symbolic",
+			source:   "grad(d(x^3, x), [2])",
+			expected: "[12.000000]",
+		},
+		{
+			desc:     "constant",
+			source:   "fn f(x) { 5 }; grad(f, [1])",
+			expected: "[0.000000]",
+		},
+		{
+			desc:     "float annotation",
+			source:   "fn area(r: float) -> float { 3 * r * r }; grad(area, [2])",
+			expected: "[12.000000]",
+		},
+		{
+			desc:     "int annotation",
+			source:   "fn f(n: int) { n }; grad(f, [2])",
+			expected: "[ERROR] type error: f: argument n: expected int got DUAL",
+		},
+		{
+			desc:     "vector result",
+			source:   "fn f(x) { [x] }; grad(f, [1])",
+			expected: "[ERROR] type error: grad: expected number result got VECTOR",
+		},
+		{
+			desc:     "point",
+			source:   "grad(sin, 1)",
+			expected: "[ERROR] type error: grad: expected vector of arguments got INTEGER",
+		},
+		{
+			desc:     "strict",
+			source:   "#strict\ngrad(sin, [true])",
+			expected: "[ERROR] type error: grad: expected numbers got BOOL",
+		},
+		{
+			desc:     "arguments count",
+			source:   "fn f(x, y) { x }; grad(f, [1])",
+			expected: "[ERROR] wrong arguments: f: expected 2 arguments got 1",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+	files := map[string]string{
+		"lib/stats.fe": "import \"util.fe\" as u\nlet n = 2\nfn mean(v) { u.sum(v) / n }\n{ let hidden = 1 }",
+		"lib/util.fe":  "fn sum(v) { v * [1, 1] }",
+		"a.fe":         "import \"b.fe\" as b\nlet x = 1",
+		"b.fe":         "import \"a.fe\" as a\nlet y = 1",
+		"syntax.fe":    "let x = 1 +",
+		"runtime.fe":   "let x = 1 / 0",
+		"entry.fe":     "import \"main.fe\" as m\nlet y = m.x",
+	}
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "member",
+			source:   "import \"lib/stats.fe\" as s\ns.mean([1.0, 2]) + s.n",
+			expected: "3.500000",
+		},
+		{
+			desc:     "module",
+			source:   "import \"lib/stats.fe\" as s; s",
+			expected: "module stats.fe",
+		},
+		{
+			desc:     "only top-level bindings are exported",
+			source:   "import \"lib/stats.fe\" as s; s.hidden",
+			expected: "[ERROR] not found: hidden in module stats.fe",
+		},
+		{
+			desc:     "imports of module are not exported",
+			source:   "import \"lib/stats.fe\" as s; s.u",
+			expected: "[ERROR] not found: u in module stats.fe",
+		},
+		{
+			desc:     "not a module",
+			source:   "let a = 1; a.x",
+			expected: "[ERROR] unsupported: INTEGER.x",
+		},
+		{
+			desc:     "cycle",
+			source:   "import \"a.fe\" as a",
+			expected: "[ERROR] import error: import cycle: a.fe -> b.fe -> a.fe",
+		},
+		{
+			desc:     "cycle with entry file",
+			source:   "import \"entry.fe\" as e\nlet x = 1\ne.y",
+			expected: "[ERROR] import error: import cycle: main.fe -> entry.fe -> main.fe",
+		},
+		{
+			desc:     "error aborts importer",
+			source:   "import \"runtime.fe\" as m\n1",
+			expected: "[ERROR] import error: runtime.fe: division by zero: 1 / 0",
+		},
+		{
+			desc:     "syntax error",
+			source:   "import \"syntax.fe\" as m",
+			expected: "[ERROR] import error: syntax.fe: 1:12: unexpected end of input",
+		},
+		{
+			desc:     "runtime error",
+			source:   "import \"runtime.fe\" as m",
+			expected: "[ERROR] import error: runtime.fe: division by zero: 1 / 0",
+		},
+	}
+	dir := t.TempDir()
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	main := filepath.Join(dir, "main.fe")
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.source))
+			program := p.Parse()
+			checkParserErrors(t, p)
+			res := evaluator.NewModules().EvalContext(context.Background(), object.NewEnv(), program, main, evaluator.Limits{})
+			if inspect := res.Inspect(); inspect != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, inspect)
+			}
+
+			bytecode, err := compiler.Compile(program)
+			if err != nil {
+				t.Fatalf("compiler error: %v", err)
+			}
+			machine := vm.New(bytecode, object.NewEnv())
+			machine.SetModules(evaluator.NewModules(), main)
+			if err := machine.Run(); err != nil {
+				t.Fatalf("vm error: %v", err)
+			}
+			if inspect := machine.Result().Inspect(); inspect != tt.expected {
+				t.Errorf("vm: expected: %s got: %s", tt.expected, inspect)
+			}
+		})
+	}
+}
+
+func TestModulesCache(t *testing.T) {
+	files := map[string]string{
+		"/lib/a.fe": "import \"c.fe\" as c\nlet x = c.z",
+		"/lib/b.fe": "import \"c.fe\" as c\nlet y = c.z",
+		"/lib/c.fe": "let z = 1",
+	}
+	reads := make(map[string]int)
+	modules := evaluator.NewModules()
+	modules.ReadFile = func(name string) ([]byte, error) {
+		reads[name]++
+		source, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(source), nil
+	}
+	p := parser.New(lexer.New("import \"a.fe\" as a\nimport \"b.fe\" as b\nimport \"./a.fe\" as a2\na.x + b.y + a2.x"))
+	program := p.Parse()
+	checkParserErrors(t, p)
+	res := modules.EvalContext(context.Background(), object.NewEnv(), program, "/lib/main.fe", evaluator.Limits{})
+	testIntegerObject(t, res, 3)
+	for name, n := range reads {
+		if n != 1 {
+			t.Errorf("%s is read %d times", name, n)
+		}
+	}
+
+	// without modules imports are errors
+	res = testEval(t, "import \"a.fe\" as a")
+	if expected := "[ERROR] import error: \"a.fe\": imports are not supported here"; res.Inspect() != expected {
+		t.Errorf("expected: %s got: %s", expected, res.Inspect())
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "permissive by default",
+			source:   "5 + (1 > 0)",
+			expected: "6",
+		},
+		{
+			desc:     "bool arithmetic",
+			source:   "#strict\n5 + (1 > 0)",
+			expected: "[ERROR] type error: INTEGER + BOOL: bool is not a number",
+		},
+		{
+			desc:     "bool ordering",
+			source:   "#strict\ntrue < 2",
+			expected: "[ERROR] type error: BOOL < INTEGER: bool is not a number",
+		},
+		{
+			desc:     "bool equal number",
+			source:   "#strict\n1 == true",
+			expected: "[ERROR] type error: INTEGER == BOOL: bool compared with number",
+		},
+		{
+			desc:     "number as bool",
+			source:   "#strict\n1 and true",
+			expected: "[ERROR] type error: INTEGER and BOOL: operands must be bool",
+		},
+		{
+			desc:     "not number",
+			source:   "#strict\n!0",
+			expected: "[ERROR] type error: !INTEGER: operand must be bool",
+		},
+		{
+			desc:     "comparison chain",
+			source:   "#strict\n0 < true < 2",
+			expected: "[ERROR] type error: INTEGER < BOOL: bool is not a number",
+		},
+		{
+			desc:     "bools are fine",
+			source:   "#strict\n1 < 2 and !false",
+			expected: "true",
+		},
+		{
+			desc:     "numbers are fine",
+			source:   "#strict\n5 + 1 == 6",
+			expected: "true",
+		},
+		{
+			desc:     "block scope",
+			source:   "{ #strict }\n5 + true",
+			expected: "6",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if res := testEval(t, tt.source).Inspect(); res != tt.expected {
+				t.Errorf("expected: %s got: %s", tt.expected, res)
+			}
+		})
+	}
+}
+
 func checkParserErrors(t *testing.T, p *parser.Parser) {
 	errs := p.Errors()
 	if !p.HasErrors() {
@@ -230,13 +1042,49 @@ func checkParserErrors(t *testing.T, p *parser.Parser) {
 	t.FailNow()
 }
 
+// backends is every way to run program, evaluator is the reference
+var backends = []struct {
+	name string
+	run  func(t *testing.T, program *ast.Program) object.Object
+}{
+	{"evaluator", func(t *testing.T, program *ast.Program) object.Object {
+		return evaluator.Eval(object.NewEnv(), program)
+	}},
+	{"vm", func(t *testing.T, program *ast.Program) object.Object {
+		bytecode, err := compiler.Compile(program)
+		if err != nil {
+			t.Fatalf("compiler error: %v", err)
+		}
+		machine := vm.New(bytecode, object.NewEnv())
+		if err := machine.Run(); err != nil {
+			t.Fatalf("vm error: %v", err)
+		}
+		return machine.Result()
+	}},
+}
+
+// testEval run source with every backend, check that they agree and return the result
 func testEval(t *testing.T, source string) object.Object {
+	t.Helper()
 	l := lexer.New(source)
 	p := parser.New(l)
 	program := p.Parse()
 	checkParserErrors(t, p)
-	env := object.NewEnv()
-	return evaluator.Eval(env, program)
+	expected := backends[0].run(t, program)
+	for _, backend := range backends[1:] {
+		res := backend.run(t, program)
+		if inspect(res) != inspect(expected) {
+			t.Errorf("%s: %q: expected %s got %s", backend.name, source, inspect(expected), inspect(res))
+		}
+	}
+	return expected
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return string(obj.Type()) + " " + obj.Inspect()
 }
 
 func testIntegerObject(t *testing.T, obj object.Object, value int64) bool {
@@ -264,3 +1112,234 @@ func testFloatObject(t *testing.T, obj object.Object, value float64) bool {
 	}
 	return true
 }
+
+func TestLimits(t *testing.T) {
+	vector := &object.Builtin{Name: "vector", Fn: func(args ...object.Object) object.Object {
+		vec := &object.Vector{}
+		for i := range args[0].(*object.Integer).Value {
+			vec.Elements = append(vec.Elements, &object.Integer{Value: i})
+		}
+		return vec
+	}}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+	testCases := []struct {
+		source   string
+		ctx      context.Context
+		limits   evaluator.Limits
+		expected string
+	}{
+		{source: "1 + 2 * 3", limits: evaluator.Limits{MaxSteps: 7}, expected: "7"},
+		{source: "1 + 2 * 3", limits: evaluator.Limits{MaxSteps: 6}, expected: "[ERROR] limit exceeded: more than 6 steps"},
+		{source: "let a = 1 + 2\n10", limits: evaluator.Limits{MaxSteps: 4}, expected: "[ERROR] limit exceeded: more than 4 steps"},
+		{source: "-(-(-1))", limits: evaluator.Limits{MaxDepth: 6}, expected: "-1"},
+		{source: "-(-(-(-1)))", limits: evaluator.Limits{MaxDepth: 6}, expected: "[ERROR] limit exceeded: nesting deeper than 6"},
+		{source: "{{{{ 1 }}}}", limits: evaluator.Limits{MaxDepth: 6}, expected: "[ERROR] limit exceeded: nesting deeper than 6"},
+		{source: "vector(3)", limits: evaluator.Limits{MaxAlloc: 3}, expected: "[0, 1, 2]"},
+		{source: "vector(4)\n1", limits: evaluator.Limits{MaxAlloc: 3}, expected: "[ERROR] limit exceeded: vector of 4 elements, maximum is 3"},
+		{source: "1 + 1", ctx: cancelled, expected: "[ERROR] limit exceeded: context canceled"},
+		{source: "fn f(x) { x * x }\nf(30!)", limits: evaluator.Limits{MaxAlloc: 2}, expected: "[ERROR] limit exceeded: integer of about 4 words, maximum is 2"},
+		{source: "300000!\n1", limits: evaluator.Limits{MaxAlloc: 1000}, expected: "words, maximum is 1000"},
+		{source: "let a = 1000!\nlet b = a * a\nlet c = b * b\nc * c\n1", limits: evaluator.Limits{MaxAlloc: 1000}, expected: "[ERROR] limit exceeded: integer of about 1068 words, maximum is 1000"},
+		{source: "2 ^ 100000\n1", limits: evaluator.Limits{MaxAlloc: 1000}, expected: "[ERROR] limit exceeded: integer of about 1563 words, maximum is 1000"},
+		{source: "300000!\n1", ctx: timeout, expected: "[ERROR] limit exceeded: context deadline exceeded"},
+		{source: "fn f(x) { x + 1 }\nf(f(1))", limits: evaluator.Limits{MaxSteps: 10}, expected: "[ERROR] limit exceeded: more than 10 steps"},
+		{source: "fn f(x) { f(x) }\nf(1)", limits: evaluator.Limits{MaxDepth: 100}, expected: "[ERROR] limit exceeded: nesting deeper than 100"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.source))
+			program := p.Parse()
+			checkParserErrors(t, p)
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			env := object.NewEnv()
+			env.Set("vector", vector)
+			if res := inspect(evaluator.EvalContext(ctx, env, program, tt.limits)); !strings.HasSuffix(res, tt.expected) {
+				t.Errorf("expected %s got %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+// TestFrozenEnv evaluate statements that bind names directly in frozen environment
+func TestFrozenEnv(t *testing.T) {
+	globals := object.NewEnv()
+	globals.Set("base", &object.Integer{Value: 10})
+	frozen := globals.Freeze()
+
+	p := parser.New(lexer.New("#strict\nlet a = 1\nfn f(x) { x + a }\nimport \"lib.fe\" as m\nf(base)"))
+	program := p.Parse()
+	checkParserErrors(t, p)
+	expected := "[ERROR] import error: \"lib.fe\": imports are not supported here"
+	if res := evaluator.Eval(frozen, program).Inspect(); res != expected {
+		t.Errorf("expected %s got %s", expected, res)
+	}
+	bytecode, err := compiler.Compile(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	machine := vm.New(bytecode, frozen)
+	if err := machine.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if res := machine.Result().Inspect(); res != expected {
+		t.Errorf("vm: expected %s got %s", expected, res)
+	}
+
+	p = parser.New(lexer.New("let a = 1\nfn f(x) { x + a }\nf(base)"))
+	program = p.Parse()
+	checkParserErrors(t, p)
+	if res := evaluator.Eval(frozen, program).Inspect(); res != "11" {
+		t.Errorf("expected 11 got %s", res)
+	}
+	if _, ok := frozen.Get("a"); ok || frozen.Strict() {
+		t.Errorf("frozen environment is changed")
+	}
+}
+
+// TestConcurrentEval evaluate many programs in parallel on top of one frozen environment,
+// run with -race
+func TestConcurrentEval(t *testing.T) {
+	globals := object.NewEnv()
+	globals.Set("rate", &object.Float{Value: 0.5})
+	globals.Set("base", &object.Integer{Value: 10})
+	shared := globals.Freeze()
+
+	p := parser.New(lexer.New("let y = x * rate + base\n{ #strict\n let z = y > 0 }\ny |> max(_, 0)"))
+	program := p.Parse()
+	checkParserErrors(t, p)
+	bytecode, err := compiler.Compile(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines, runs = 16, 200
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+
+	// globals are still writable, evaluations don't see changes
+	stop := make(chan struct{})
+	writer := make(chan struct{})
+	go func() {
+		defer close(writer)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				globals.Set("rate", &object.Integer{Value: int64(i)})
+			}
+		}
+	}()
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range runs {
+				x := int64(g*runs + i)
+				expected := float64(x)*0.5 + 10
+
+				env := shared.SubEnv()
+				env.Set("x", &object.Integer{Value: x})
+				res := evaluator.Eval(env, program)
+
+				vmEnv := shared.SubEnv()
+				vmEnv.Set("x", &object.Integer{Value: x})
+				machine := vm.New(bytecode, vmEnv)
+				if err := machine.Run(); err != nil {
+					errs <- err
+					return
+				}
+				for _, res := range []object.Object{res, machine.Result()} {
+					if f, ok := res.(*object.Float); !ok || f.Value != expected {
+						errs <- fmt.Errorf("x = %d: expected %f got %s", x, expected, inspect(res))
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-writer
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestConcurrentClosure call closure that is made in block of function body
+// on top of frozen environment while globals are changed, run with -race
+func TestConcurrentClosure(t *testing.T) {
+	globals := object.NewEnv()
+	p := parser.New(lexer.New("let a = 1\nfn make() { { fn g(x) { x + a }\ng } }\nlet h = make()"))
+	program := p.Parse()
+	checkParserErrors(t, p)
+	if res := evaluator.Eval(globals, program); res != nil && object.IsError(res) {
+		t.Fatal(res.Inspect())
+	}
+	shared := globals.Freeze()
+
+	p = parser.New(lexer.New("h(x)"))
+	program = p.Parse()
+	checkParserErrors(t, p)
+	bytecode, err := compiler.Compile(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines, runs = 8, 100
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	stop := make(chan struct{})
+	writer := make(chan struct{})
+	go func() {
+		defer close(writer)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				globals.Set("a", &object.Integer{Value: int64(i)})
+			}
+		}
+	}()
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range runs {
+				x := int64(g*runs + i)
+				env := shared.SubEnv()
+				env.Set("x", &object.Integer{Value: x})
+				res := evaluator.Eval(env, program)
+
+				vmEnv := shared.SubEnv()
+				vmEnv.Set("x", &object.Integer{Value: x})
+				machine := vm.New(bytecode, vmEnv)
+				if err := machine.Run(); err != nil {
+					errs <- err
+					return
+				}
+				for _, res := range []object.Object{res, machine.Result()} {
+					if n, ok := res.(*object.Integer); !ok || n.Value != x+1 {
+						errs <- fmt.Errorf("x = %d: expected %d got %s", x, x+1, inspect(res))
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-writer
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
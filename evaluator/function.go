@@ -0,0 +1,145 @@
+package evaluator
+
+import (
+	"context"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/object"
+)
+
+// MaxCalls is maximum nesting of function calls, deeper recursion
+// would overflow Go stack long before any limit is exceeded
+const MaxCalls = 1000
+
+// Annotate check value against annotation typ of binding name.
+// Integers are widened to float like in Integer.Add(*Float),
+// bool is never a number here even outside of strict mode
+func Annotate(name, typ string, value object.Object) object.Object {
+	if object.IsError(value) {
+		return value
+	}
+	if res, ok := convert(value, typ); ok {
+		return res
+	}
+	return object.NewError(object.TYPE_ERR, "%s: expected %s got %s", name, typ, value.Type())
+}
+
+func convert(value object.Object, typ string) (object.Object, bool) {
+	switch typ {
+	case "int":
+		switch value.(type) {
+		case *object.Integer, *object.BigInt:
+			return value, true
+		}
+	case "float":
+		switch value := value.(type) {
+		case *object.Float, *object.Dual:
+			// dual is a float that carries derivative
+			return value, true
+		case *object.Integer:
+			return &object.Float{Value: float64(value.Value)}, true
+		case *object.BigInt:
+			return value.AsFloat(), true
+		}
+	case "bool":
+		if _, ok := value.(*object.Bool); ok {
+			return value, true
+		}
+	case "vector":
+		if _, ok := value.(*object.Vector); ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// CallContext call function like Call, but functions declared by fn, symbolics
+// and grad are evaluated with limits like Apply
+func CallContext(ctx context.Context, function object.Object, args []object.Object, strict bool, limits Limits, steps, depth int) (object.Object, int) {
+	s := &state{done: ctx.Done(), ctx: ctx, limits: limits, steps: steps, depth: depth}
+	res := s.checkAlloc(s.call(function, args, strict))
+	return res, s.steps
+}
+
+// call apply function, the ones that evaluate ferret code continue counting of limits
+func (s *state) call(function object.Object, args []object.Object, strict bool) object.Object {
+	switch fn := function.(type) {
+	case *object.Function:
+		return s.apply(fn, args)
+	case *object.Symbolic:
+		return s.substitute(fn, args)
+	case *object.Builtin:
+		if fn == gradBuiltin {
+			return s.grad(args, strict)
+		}
+	}
+	return Call(function, args, strict)
+}
+
+// Apply call function declared by fn with limits like EvalContext.
+// Counting starts from steps and depth of caller, the result is returned
+// with steps counted by the end of call
+func Apply(ctx context.Context, fn *object.Function, args []object.Object, limits Limits, steps, depth int) (object.Object, int) {
+	s := &state{done: ctx.Done(), ctx: ctx, limits: limits, steps: steps, depth: depth}
+	res := s.checkAlloc(s.apply(fn, args))
+	return res, s.steps
+}
+
+// apply bind arguments to parameters in sub environment of function
+// and evaluate its body, the result is value of the last statement
+func (s *state) apply(fn *object.Function, args []object.Object) object.Object {
+	env, err := Bind(fn, args, s.calls)
+	if err != nil {
+		return err
+	}
+	s.calls++
+	res := s.evalStatements(env, fn.Definition.Body.Statements)
+	s.calls--
+	return Result(fn, res)
+}
+
+// Bind check arguments of fn and bind them to parameters in sub environment of function,
+// calls is nesting of function calls of caller. It's shared with vm
+func Bind(fn *object.Function, args []object.Object, calls int) (*object.Environment, object.Object) {
+	for _, arg := range args {
+		if object.IsError(arg) {
+			return nil, arg
+		}
+	}
+	def := fn.Definition
+	if len(args) != len(def.Parameters) {
+		return nil, object.NewError(object.ARGUMENTS_ERR, "%s: expected %d arguments got %d", fn.Name(), len(def.Parameters), len(args))
+	}
+	if calls >= MaxCalls {
+		return nil, object.NewError(object.RECURSION_ERR, "%s: more than %d nested calls", fn.Name(), MaxCalls)
+	}
+
+	env := fn.Env.SubEnv()
+	for i, param := range def.Parameters {
+		arg := args[i]
+		if param.Type != nil {
+			arg = Annotate(fn.Name()+": argument "+param.Name.Value, param.Type.Name, arg)
+			if object.IsError(arg) {
+				return nil, arg
+			}
+		}
+		env.Set(param.Name.Value, arg)
+	}
+	return env, nil
+}
+
+// Result check res of the last statement of fn body against return type
+func Result(fn *object.Function, res object.Object) object.Object {
+	switch {
+	case res == nil:
+		return object.NewError(object.TYPE_ERR, "%s: no result, the last statement of body is not an expression", fn.Name())
+	case fn.Definition.ReturnType != nil:
+		return Annotate(fn.Name()+": result", fn.Definition.ReturnType.Name, res)
+	}
+	return res
+}
+
+// declare bind function in environment where fn statement is
+func declare(env *object.Environment, def *ast.FunctionStatement) {
+	env.Set(def.Name.Value, &object.Function{Definition: def, Env: env})
+}
@@ -0,0 +1,62 @@
+package evaluator
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Richtermnd/ferret/object"
+)
+
+// gradBuiltin is grad(f, [x, y]): vector of partial derivatives of f at the point.
+// It calls ferret functions, so evaluator and vm apply it with their limits,
+// Fn is for calls without them like Call
+var gradBuiltin = &object.Builtin{Name: "grad"}
+
+func init() {
+	gradBuiltin.Fn = func(args ...object.Object) object.Object {
+		res, _ := CallContext(context.Background(), gradBuiltin, args, false, Limits{}, 0, 0)
+		return res
+	}
+	object.Register(gradBuiltin)
+}
+
+// grad call f once for every argument with it seeded as dual x + ε,
+// ε part of the result is partial derivative by this argument (forward mode)
+func (s *state) grad(args []object.Object, strict bool) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ARGUMENTS_ERR, "grad: expected 2 arguments got %d", len(args))
+	}
+	for _, arg := range args {
+		if object.IsError(arg) {
+			return arg
+		}
+	}
+	f := args[0]
+	point, ok := args[1].(*object.Vector)
+	if !ok {
+		return object.NewError(object.TYPE_ERR, "grad: expected vector of arguments got %s", args[1].Type())
+	}
+	for _, x := range point.Elements {
+		if _, ok := object.AsNative(x); !ok || strict && isBool(x) {
+			return object.NewError(object.TYPE_ERR, "grad: expected numbers got %s", x.Type())
+		}
+	}
+
+	res := make([]object.Object, len(point.Elements))
+	for i, x := range point.Elements {
+		v, _ := object.AsNative(x)
+		seeded := slices.Clone(point.Elements)
+		seeded[i] = &object.Dual{Real: v, Eps: 1}
+		y := s.call(f, seeded, strict)
+		if object.IsError(y) {
+			return y
+		}
+		// numbers don't depend on argument
+		d, ok := object.AsDual(y)
+		if !ok {
+			return object.NewError(object.TYPE_ERR, "grad: expected number result got %s", y.Type())
+		}
+		res[i] = &object.Float{Value: d.Eps}
+	}
+	return &object.Vector{Elements: res}
+}
@@ -0,0 +1,168 @@
+package evaluator
+
+import (
+	"context"
+	"math"
+
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Limits restrict evaluation of untrusted programs, zero value means no limit
+type Limits struct {
+	// MaxSteps is maximum number of evaluated nodes
+	MaxSteps int
+	// MaxDepth is maximum nesting of nodes being evaluated
+	MaxDepth int
+	// MaxAlloc is maximum number of elements in vector returned by function,
+	// elements of nested vectors are counted too (ferret has no strings).
+	// BigInt counts as its number of 64-bit words, factorial and
+	// products and powers of integers are checked before they are computed
+	MaxAlloc int
+}
+
+// state of one evaluation
+type state struct {
+	ctx    context.Context
+	done   <-chan struct{}
+	limits Limits
+
+	steps int
+	depth int
+	// calls is nesting of function calls
+	calls int
+
+	// modules load imports relative to file, nil if imports are not supported
+	modules *Modules
+	file    string
+
+	// err is the limit error, once set evaluation stops
+	err object.Object
+}
+
+func (s *state) enter() object.Object {
+	if s.err != nil {
+		return s.err
+	}
+	s.steps++
+	s.depth++
+	if s.done != nil {
+		select {
+		case <-s.done:
+			s.err = object.NewError(object.LIMIT_ERR, "%v", s.ctx.Err())
+		default:
+		}
+	}
+	switch {
+	case s.err != nil:
+	case s.limits.MaxSteps > 0 && s.steps > s.limits.MaxSteps:
+		s.err = object.NewError(object.LIMIT_ERR, "more than %d steps", s.limits.MaxSteps)
+	case s.limits.MaxDepth > 0 && s.depth > s.limits.MaxDepth:
+		s.err = object.NewError(object.LIMIT_ERR, "nesting deeper than %d", s.limits.MaxDepth)
+	}
+	if s.err != nil {
+		s.depth--
+	}
+	return s.err
+}
+
+// checkLimit stop evaluation if res is limit error
+func (s *state) checkLimit(res object.Object) object.Object {
+	if object.IsLimitError(res) {
+		s.err = res
+	}
+	return res
+}
+
+// checkAlloc replace too big vectors with limit error
+func (s *state) checkAlloc(obj object.Object) object.Object {
+	if err := CheckAlloc(obj, s.limits); err != nil {
+		s.err = err
+		return err
+	}
+	return obj
+}
+
+// CheckAlloc return limit error if size of obj is bigger than limits.MaxAlloc
+func CheckAlloc(obj object.Object, limits Limits) object.Object {
+	if limits.MaxAlloc <= 0 {
+		return nil
+	}
+	if n := size(obj); n > limits.MaxAlloc {
+		if _, ok := obj.(*object.BigInt); ok {
+			return object.NewError(object.LIMIT_ERR, "integer of %d words, maximum is %d", n, limits.MaxAlloc)
+		}
+		return object.NewError(object.LIMIT_ERR, "vector of %d elements, maximum is %d", n, limits.MaxAlloc)
+	}
+	return nil
+}
+
+// checkInfix return limit error if product or power of integers would be bigger
+// than limits.MaxAlloc, it's estimated without computing it
+func checkInfix(tok token.Token, left, right object.Object, limits Limits) object.Object {
+	if limits.MaxAlloc <= 0 {
+		return nil
+	}
+	var n float64
+	switch tok.Type {
+	case token.MUL:
+		_, lBig := left.(*object.BigInt)
+		_, rBig := right.(*object.BigInt)
+		if !lBig && !rBig {
+			return nil
+		}
+		n = float64(intWords(left) + intWords(right))
+	case token.POW:
+		exp, ok := right.(*object.Integer)
+		if !ok || exp.Value < 0 {
+			return nil
+		}
+		// log2(base^exp) = exp * log2(base)
+		var bits float64
+		switch base := left.(type) {
+		case *object.Integer:
+			bits = math.Log2(math.Abs(float64(base.Value)))
+		case *object.BigInt:
+			bits = float64(base.Value.BitLen())
+		default:
+			return nil
+		}
+		n = math.Ceil(float64(exp.Value) * bits / 64)
+	}
+	if n > float64(limits.MaxAlloc) {
+		return object.NewError(object.LIMIT_ERR, "integer of about %.0f words, maximum is %d", n, limits.MaxAlloc)
+	}
+	return nil
+}
+
+// intWords is number of words of integer operand
+func intWords(obj object.Object) int {
+	switch obj := obj.(type) {
+	case *object.BigInt:
+		return len(obj.Value.Bits())
+	case *object.Integer:
+		return 1
+	}
+	return 0
+}
+
+// size is number of elements in vector and its nested vectors,
+// BigInt is number of its words
+func size(obj object.Object) int {
+	if n, ok := obj.(*object.BigInt); ok {
+		return words(n.Value.BitLen())
+	}
+	vec, ok := obj.(*object.Vector)
+	if !ok {
+		return 0
+	}
+	n := len(vec.Elements)
+	for _, el := range vec.Elements {
+		n += size(el)
+	}
+	return n
+}
+
+func words(bits int) int {
+	return (bits + 63) / 64
+}
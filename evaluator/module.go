@@ -0,0 +1,158 @@
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/parser"
+)
+
+// Modules load files imported by import statement, each file is evaluated once
+// in its own environment and cached by absolute path. It's not safe for concurrent use
+type Modules struct {
+	// ReadFile read source of module, it's os.ReadFile by default
+	ReadFile func(name string) ([]byte, error)
+
+	cache map[string]*object.Module
+	// loading is stack of modules being evaluated, used to detect cycles
+	loading []string
+}
+
+func NewModules() *Modules {
+	return &Modules{ReadFile: os.ReadFile, cache: make(map[string]*object.Module)}
+}
+
+// EvalContext is EvalContext of package with imports resolved relative to file,
+// file is "" for source without file, then imports are relative to working directory
+func (m *Modules) EvalContext(ctx context.Context, env *object.Environment, node ast.Node, file string, limits Limits) object.Object {
+	defer m.enter(file)()
+	s := &state{done: ctx.Done(), ctx: ctx, limits: limits, modules: m, file: file}
+	return s.eval(writable(env), node)
+}
+
+// Import load module path imported from file, steps and depth are counted like in Apply
+func (m *Modules) Import(ctx context.Context, from, path string, limits Limits, steps, depth int) (object.Object, int) {
+	defer m.enter(from)()
+	s := &state{done: ctx.Done(), ctx: ctx, limits: limits, steps: steps, depth: depth, modules: m, file: from}
+	return s.load(path), s.steps
+}
+
+// enter mark file as being evaluated until returned func is called,
+// so import of it from its own imports is a cycle
+func (m *Modules) enter(file string) func() {
+	abs, err := filepath.Abs(file)
+	if file == "" || err != nil || slices.Contains(m.loading, abs) {
+		return func() {}
+	}
+	m.loading = append(m.loading, abs)
+	return func() { m.loading = m.loading[:len(m.loading)-1] }
+}
+
+// evalImport bind module to alias of import statement
+func (s *state) evalImport(env *object.Environment, node *ast.ImportStatement) object.Object {
+	if s.modules == nil {
+		return object.NewError(object.IMPORT_ERR, "%q: imports are not supported here", node.Path.Literal)
+	}
+	module := s.load(node.Path.Literal)
+	if object.IsError(module) {
+		return module
+	}
+	env.Set(node.Alias.Value, module)
+	return nil
+}
+
+// load return cached module or evaluate it, path is relative to directory of current file
+func (s *state) load(path string) object.Object {
+	m := s.modules
+	if !filepath.IsAbs(path) && s.file != "" {
+		path = filepath.Join(filepath.Dir(s.file), path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return object.NewError(object.IMPORT_ERR, "%v", err)
+	}
+	if module, ok := m.cache[abs]; ok {
+		return module
+	}
+	for i, loading := range m.loading {
+		if loading == abs {
+			return object.NewError(object.IMPORT_ERR, "import cycle: %s", cycle(append(m.loading[i:], abs)))
+		}
+	}
+
+	source, err := m.ReadFile(abs)
+	if err != nil {
+		return object.NewError(object.IMPORT_ERR, "%v", err)
+	}
+	name := filepath.Base(abs)
+	p := parser.New(lexer.New(string(source)))
+	program := p.Parse()
+	if p.HasErrors() {
+		return object.NewError(object.IMPORT_ERR, "%s: %v", name, p.Errors()[0])
+	}
+
+	m.loading = append(m.loading, abs)
+	file := s.file
+	s.file = abs
+	module, res := s.evalModule(abs, program)
+	s.file = file
+	m.loading = m.loading[:len(m.loading)-1]
+
+	if res != nil {
+		// errors of nested imports already name their module
+		if err := res.(*object.Error); err.ErrType != object.LIMIT_ERR && err.ErrType != object.IMPORT_ERR {
+			return object.NewError(object.IMPORT_ERR, "%s: %s", name, err.Error())
+		}
+		return res
+	}
+	m.cache[abs] = module
+	return module
+}
+
+// evalModule evaluate top-level statements of module in new environment,
+// it stops on the first error
+func (s *state) evalModule(path string, program *ast.Program) (*object.Module, object.Object) {
+	module := &object.Module{Path: path, Env: object.NewEnv()}
+	for _, stmt := range program.Statements {
+		if res := s.eval(module.Env, stmt); res != nil && object.IsError(res) {
+			return nil, res
+		}
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			module.Exports = append(module.Exports, stmt.Name.Value)
+		case *ast.FunctionStatement:
+			module.Exports = append(module.Exports, stmt.Name.Value)
+		}
+	}
+	return module, nil
+}
+
+// cycle return chain of imports with base names of files
+func cycle(paths []string) string {
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	return strings.Join(names, " -> ")
+}
+
+// Select return member name of obj, only modules have members
+func Select(obj object.Object, name string) object.Object {
+	if object.IsError(obj) {
+		return obj
+	}
+	module, ok := obj.(*object.Module)
+	if !ok {
+		return object.NewError(object.UNSUPPORTED_ERR, "%s.%s", obj.Type(), name)
+	}
+	if member, ok := module.Get(name); ok {
+		return member
+	}
+	return object.NewError(object.NOT_FOUND_ERR, "%s in %s", name, module.Inspect())
+}
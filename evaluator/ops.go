@@ -0,0 +1,106 @@
+package evaluator
+
+import (
+	"context"
+
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Operations on evaluated operands, shared by Eval and vm.
+// Error operand is returned as is (left before right),
+// strict enables strict mode checks.
+
+func Infix(tok token.Token, left, right object.Object, strict bool) object.Object {
+	if object.IsError(left) {
+		return left
+	}
+	if object.IsError(right) {
+		return right
+	}
+	if strict {
+		if err := strictInfix(tok, left, right); err != nil {
+			return err
+		}
+	}
+	if isVector(left) || isVector(right) {
+		return vectorInfix(tok, left, right, strict)
+	}
+	return evalInfixExpression(tok, left, right)
+}
+
+// InfixLimits is Infix that fails when the result is bigger than limits.MaxAlloc,
+// products and powers of integers fail before they are computed
+func InfixLimits(tok token.Token, left, right object.Object, strict bool, limits Limits) object.Object {
+	if err := checkInfix(tok, left, right, limits); err != nil {
+		return err
+	}
+	res := Infix(tok, left, right, strict)
+	if err := CheckAlloc(res, limits); err != nil {
+		return err
+	}
+	return res
+}
+
+func Prefix(op string, right object.Object, strict bool) object.Object {
+	if object.IsError(right) {
+		return right
+	}
+	if strict {
+		if err := strictPrefix(op, right); err != nil {
+			return err
+		}
+	}
+	return evalPrefixExpression(op, right)
+}
+
+// PrefixLimits is Prefix that fails when the result is bigger than limits.MaxAlloc
+func PrefixLimits(op string, right object.Object, strict bool, limits Limits) object.Object {
+	res := Prefix(op, right, strict)
+	if err := CheckAlloc(res, limits); err != nil {
+		return err
+	}
+	return res
+}
+
+func Postfix(op string, left object.Object, strict bool) object.Object {
+	return PostfixContext(context.Background(), op, left, strict, Limits{})
+}
+
+// PostfixContext is Postfix with factorial that stops when ctx is done
+// and fails without computing when its result is bigger than limits.MaxAlloc
+func PostfixContext(ctx context.Context, op string, left object.Object, strict bool, limits Limits) object.Object {
+	if object.IsError(left) {
+		return left
+	}
+	if strict {
+		if err := strictPostfix(op, left); err != nil {
+			return err
+		}
+	}
+	return evalPostfixExpression(ctx, op, left, limits)
+}
+
+// Call apply function to arguments, the first error among them is returned instead
+func Call(function object.Object, args []object.Object, strict bool) object.Object {
+	if object.IsError(function) {
+		return function
+	}
+	for _, arg := range args {
+		if object.IsError(arg) {
+			return arg
+		}
+	}
+	if strict {
+		if err := strictCall(function, args); err != nil {
+			return err
+		}
+	}
+	return applyFunction(function, args)
+}
+
+// IsTrue report if obj is true bool, comparison chains stop on anything else
+func IsTrue(obj object.Object) bool {
+	b, ok := obj.(*object.Bool)
+	return ok && b.Value
+}
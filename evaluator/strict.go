@@ -0,0 +1,66 @@
+package evaluator
+
+import (
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// In strict mode (#strict or -strict flag) bools are not integers
+// and numbers are not bools, so 5 + (1 > 0) and 1 and 2 are type errors.
+// These checks return nil if operation is allowed.
+
+func isBool(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.BOOL_OBJ
+}
+
+func strictInfix(tok token.Token, left, right object.Object) object.Object {
+	if object.IsError(left) || object.IsError(right) {
+		return nil
+	}
+	lBool, rBool := isBool(left), isBool(right)
+	switch tok.Type {
+	case token.AND, token.OR:
+		if !lBool || !rBool {
+			return object.NewError(object.TYPE_ERR, "%s %s %s: operands must be bool", left.Type(), tok.Literal, right.Type())
+		}
+	case token.EQ, token.NEQ:
+		if lBool != rBool {
+			return object.NewError(object.TYPE_ERR, "%s %s %s: bool compared with number", left.Type(), tok.Literal, right.Type())
+		}
+	default:
+		if lBool || rBool {
+			return object.NewError(object.TYPE_ERR, "%s %s %s: bool is not a number", left.Type(), tok.Literal, right.Type())
+		}
+	}
+	return nil
+}
+
+func strictPrefix(op string, right object.Object) object.Object {
+	if op == "!" && !isBool(right) && !object.IsError(right) {
+		return object.NewError(object.TYPE_ERR, "!%s: operand must be bool", right.Type())
+	}
+	return nil
+}
+
+func strictPostfix(op string, left object.Object) object.Object {
+	if isBool(left) {
+		return object.NewError(object.TYPE_ERR, "%s%s: bool is not a number", left.Type(), op)
+	}
+	return nil
+}
+
+func strictCall(function object.Object, args []object.Object) object.Object {
+	builtin, ok := function.(*object.Builtin)
+	if !ok {
+		return nil
+	}
+	if builtin.Strict != nil {
+		return builtin.Strict(args)
+	}
+	for _, arg := range args {
+		if isBool(arg) {
+			return object.NewError(object.TYPE_ERR, "%s: bool is not a number", function.Inspect())
+		}
+	}
+	return nil
+}
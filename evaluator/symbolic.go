@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"context"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/format"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/symbolic"
+)
+
+// IsDerive report if call is d(expr, x): d isn't a function, its arguments aren't evaluated.
+// Bound d is an ordinary name
+func IsDerive(env *object.Environment, call *ast.CallExpression) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok || ident.Value != "d" {
+		return false
+	}
+	_, bound := env.Get("d")
+	return !bound
+}
+
+// Derive evaluate d(expr, x) to symbolic derivative of expr by x.
+// Names bound to symbolics are replaced with their expressions and calls of them
+// with expressions of arguments, so derivatives can be differentiated again
+func Derive(env *object.Environment, call *ast.CallExpression) object.Object {
+	if len(call.Arguments) != 2 {
+		return object.NewError(object.ARGUMENTS_ERR, "d: expected 2 arguments got %d", len(call.Arguments))
+	}
+	x, ok := call.Arguments[1].(*ast.Identifier)
+	if !ok {
+		return object.NewError(object.TYPE_ERR, "d: expected name of variable got %s", format.Node(call.Arguments[1]))
+	}
+	expr, err := inline(env, call.Arguments[0])
+	if err != nil {
+		return err
+	}
+	res, derr := symbolic.Derive(expr, x.Value)
+	if derr != nil {
+		return object.NewError(object.UNSUPPORTED_ERR, "d: %s", derr)
+	}
+	return &object.Symbolic{Expr: res, Var: x.Value, Env: env}
+}
+
+// inline replace names bound to symbolics with their expressions,
+// calls of other bound names can't be differentiated even if they shadow builtins
+func inline(env *object.Environment, expr ast.Expression) (ast.Expression, object.Object) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		if sym, ok := lookupSymbolic(env, expr.Value); ok {
+			return sym.Expr, nil
+		}
+
+	case *ast.PrefixExpression:
+		right, err := inline(env, expr.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.PrefixExpression{Token: expr.Token, Operator: expr.Operator, Right: right}, nil
+
+	case *ast.PostfixExpression:
+		left, err := inline(env, expr.Left)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.PostfixExpression{Token: expr.Token, Operator: expr.Operator, Left: left}, nil
+
+	case *ast.InfixExpression:
+		left, err := inline(env, expr.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := inline(env, expr.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.InfixExpression{Token: expr.Token, Operator: expr.Operator, Left: left, Right: right}, nil
+
+	case *ast.CallExpression:
+		args := make([]ast.Expression, len(expr.Arguments))
+		for i, arg := range expr.Arguments {
+			inlined, err := inline(env, arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = inlined
+		}
+		ident, ok := expr.Function.(*ast.Identifier)
+		if !ok {
+			break
+		}
+		if _, bound := env.Get(ident.Value); !bound {
+			return &ast.CallExpression{Token: expr.Token, Function: expr.Function, Arguments: args, Piped: expr.Piped}, nil
+		}
+		sym, ok := lookupSymbolic(env, ident.Value)
+		if !ok {
+			return nil, object.NewError(object.UNSUPPORTED_ERR, "d: can't differentiate %s: derivative of %s is unknown", format.Node(expr), ident.Value)
+		}
+		if len(args) != 1 {
+			return nil, object.NewError(object.ARGUMENTS_ERR, "%s: expected 1 arguments got %d", ident.Value, len(args))
+		}
+		return symbolic.Replace(sym.Expr, sym.Var, args[0]), nil
+	}
+	return expr, nil
+}
+
+func lookupSymbolic(env *object.Environment, name string) (*object.Symbolic, bool) {
+	obj, _ := env.Get(name)
+	sym, ok := obj.(*object.Symbolic)
+	return sym, ok
+}
+
+// Substitute evaluate symbolic at args with limits like Apply
+func Substitute(ctx context.Context, sym *object.Symbolic, args []object.Object, limits Limits, steps, depth int) (object.Object, int) {
+	s := &state{done: ctx.Done(), ctx: ctx, limits: limits, steps: steps, depth: depth}
+	res := s.checkAlloc(s.substitute(sym, args))
+	return res, s.steps
+}
+
+// substitute evaluate expression of symbolic in sub environment of its Env
+// with variable bound to the only argument
+func (s *state) substitute(sym *object.Symbolic, args []object.Object) object.Object {
+	for _, arg := range args {
+		if object.IsError(arg) {
+			return arg
+		}
+	}
+	if len(args) != 1 {
+		return object.NewError(object.ARGUMENTS_ERR, "%s: expected 1 arguments got %d", sym.Inspect(), len(args))
+	}
+	env := sym.Env.SubEnv()
+	env.Set(sym.Var, args[0])
+	return s.eval(env, sym.Expr)
+}
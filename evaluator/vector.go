@@ -0,0 +1,185 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Vector arithmetic. + and - are element-wise for vectors of the same shape,
+// * and / by number scale every element, * of two vectors is a product:
+// dot product of vectors, matrix by vector, vector by matrix and matrix by matrix.
+// Bools are not numbers here even without strict mode: true * [1, 2] is an error
+
+func isVector(obj object.Object) bool {
+	_, ok := obj.(*object.Vector)
+	return ok
+}
+
+// isNumber report if obj can scale vector
+func isNumber(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.Float, *object.BigInt, *object.Dual:
+		return true
+	}
+	return false
+}
+
+func vectorInfix(tok token.Token, left, right object.Object, strict bool) object.Object {
+	lv, lok := left.(*object.Vector)
+	rv, rok := right.(*object.Vector)
+	switch tok.Type {
+	case token.ADD, token.SUB:
+		if lok && rok {
+			return elementwise(tok, lv, rv, strict)
+		}
+	case token.MUL:
+		switch {
+		case lok && rok:
+			return product(lv, rv, strict)
+		case lok && isNumber(right):
+			return scale(tok, lv, right, false, strict)
+		case rok && isNumber(left):
+			return scale(tok, rv, left, true, strict)
+		}
+	case token.DIV:
+		if lok && isNumber(right) {
+			return scale(tok, lv, right, false, strict)
+		}
+	}
+	return object.NewError(object.UNSUPPORTED_ERR, "%s %s %s", left.Type(), tok.Literal, right.Type())
+}
+
+func elementwise(tok token.Token, left, right *object.Vector, strict bool) object.Object {
+	if len(left.Elements) != len(right.Elements) {
+		return object.NewError(object.SHAPE_ERR, "%s %s %s", shape(left), tok.Literal, shape(right))
+	}
+	res := make([]object.Object, len(left.Elements))
+	for i := range left.Elements {
+		res[i] = Infix(tok, left.Elements[i], right.Elements[i], strict)
+		if object.IsError(res[i]) {
+			return res[i]
+		}
+	}
+	return &object.Vector{Elements: res}
+}
+
+// scale apply operator to every element and number, numberLeft is for 2 * v
+func scale(tok token.Token, v *object.Vector, number object.Object, numberLeft, strict bool) object.Object {
+	res := make([]object.Object, len(v.Elements))
+	for i, el := range v.Elements {
+		if numberLeft {
+			res[i] = Infix(tok, number, el, strict)
+		} else {
+			res[i] = Infix(tok, el, number, strict)
+		}
+		if object.IsError(res[i]) {
+			return res[i]
+		}
+	}
+	return &object.Vector{Elements: res}
+}
+
+// product multiply vectors as matrices, vector is a row on the left and a column on the right
+func product(left, right *object.Vector, strict bool) object.Object {
+	lRows, lMatrix := rows(left)
+	rRows, rMatrix := rows(right)
+	var ok bool
+	switch {
+	case !lMatrix && !rMatrix:
+		ok = len(left.Elements) == len(right.Elements)
+	case lMatrix && !rMatrix:
+		ok = len(lRows[0]) == len(right.Elements)
+	case !lMatrix && rMatrix:
+		ok = len(left.Elements) == len(rRows)
+	default:
+		ok = len(lRows[0]) == len(rRows)
+	}
+	if !ok {
+		return object.NewError(object.SHAPE_ERR, "%s * %s", shape(left), shape(right))
+	}
+
+	switch {
+	case !lMatrix && !rMatrix:
+		return dot(left.Elements, right.Elements, strict)
+	case lMatrix && !rMatrix:
+		return mapRows(len(lRows), func(i int) object.Object {
+			return dot(lRows[i], right.Elements, strict)
+		})
+	case !lMatrix && rMatrix:
+		return mapRows(len(rRows[0]), func(j int) object.Object {
+			return dot(left.Elements, column(rRows, j), strict)
+		})
+	}
+	return mapRows(len(lRows), func(i int) object.Object {
+		return mapRows(len(rRows[0]), func(j int) object.Object {
+			return dot(lRows[i], column(rRows, j), strict)
+		})
+	})
+}
+
+var (
+	addToken = token.NoLiteralToken(token.ADD)
+	mulToken = token.NoLiteralToken(token.MUL)
+)
+
+func dot(a, b []object.Object, strict bool) object.Object {
+	var sum object.Object = &object.Integer{Value: 0}
+	for i := range a {
+		sum = Infix(addToken, sum, Infix(mulToken, a[i], b[i], strict), strict)
+		if object.IsError(sum) {
+			return sum
+		}
+	}
+	return sum
+}
+
+// mapRows make vector of n elements, the first error is returned instead
+func mapRows(n int, f func(i int) object.Object) object.Object {
+	res := make([]object.Object, n)
+	for i := range res {
+		res[i] = f(i)
+		if object.IsError(res[i]) {
+			return res[i]
+		}
+	}
+	return &object.Vector{Elements: res}
+}
+
+// rows return elements of rows if v is a matrix:
+// not empty vector of vectors of the same length
+func rows(v *object.Vector) ([][]object.Object, bool) {
+	if len(v.Elements) == 0 {
+		return nil, false
+	}
+	first, ok := v.Elements[0].(*object.Vector)
+	if !ok {
+		return nil, false
+	}
+	res := make([][]object.Object, len(v.Elements))
+	for i, el := range v.Elements {
+		row, ok := el.(*object.Vector)
+		if !ok || len(row.Elements) != len(first.Elements) {
+			return nil, false
+		}
+		res[i] = row.Elements
+	}
+	return res, true
+}
+
+func column(rows [][]object.Object, j int) []object.Object {
+	res := make([]object.Object, len(rows))
+	for i, row := range rows {
+		res[i] = row[j]
+	}
+	return res
+}
+
+// shape describe vector size: 3 for vector, 2x3 for matrix
+func shape(v *object.Vector) string {
+	if rows, ok := rows(v); ok {
+		return fmt.Sprintf("%dx%d", len(rows), len(rows[0]))
+	}
+	return fmt.Sprint(len(v.Elements))
+}
@@ -0,0 +1,170 @@
+// Package ferret embeds ferret into Go programs:
+//
+//	interp := ferret.New()
+//	interp.Set("price", 200)
+//	v, err := interp.Eval(ctx, "price * (1 + 20%)")
+//
+// Interpreter is not safe for concurrent use
+package ferret
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/compiler"
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/optimizer"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/vm"
+)
+
+// Interpreter keeps global environment, names bound by Set and let statements of Eval
+// are visible to next evaluations
+type Interpreter struct {
+	env    *object.Environment
+	limits Limits
+}
+
+// Limits restrict evaluations of untrusted sources, see evaluator.Limits
+type Limits = evaluator.Limits
+
+// SetLimits set limits for next evaluations, zero Limits remove them
+func (interp *Interpreter) SetLimits(limits Limits) {
+	interp.limits = limits
+}
+
+func New() *Interpreter {
+	return &Interpreter{env: object.NewEnv()}
+}
+
+// SetStrict turn on/off strict mode, see #strict pragma
+func (interp *Interpreter) SetStrict(strict bool) {
+	interp.env.SetStrict(strict)
+}
+
+// Set bind Go value to name, see ToObject for supported types
+func (interp *Interpreter) Set(name string, value any) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+	interp.env.Set(name, obj)
+	return nil
+}
+
+// Get return value bound to name
+func (interp *Interpreter) Get(name string) (Value, bool) {
+	obj, ok := interp.env.Get(name)
+	return Value{obj: obj}, ok
+}
+
+// Eval evaluate source in global environment and return the value of the last statement.
+// Syntax errors are returned as *ParseError and the first runtime one as *object.Error,
+// exceeded limits are errors with LIMIT_ERR type that also wrap ctx.Err() on cancellation
+func (interp *Interpreter) Eval(ctx context.Context, src string) (Value, error) {
+	program, err := parse(src)
+	if err != nil {
+		return Value{}, err
+	}
+	return result(ctx, evaluator.EvalContext(ctx, interp.env, program, interp.limits))
+}
+
+// Program is compiled source that can be evaluated many times
+type Program struct {
+	interp   *Interpreter
+	bytecode *compiler.Bytecode
+}
+
+// Compile parse, optimize and compile source for evaluation with Program.Eval.
+// Constant expressions that always fail like 1/0 are reported as *ParseError.
+// Calls of builtins with constant arguments are computed here,
+// so bindings of Program.Eval can't replace builtins
+func (interp *Interpreter) Compile(src string) (*Program, error) {
+	program, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	program, diagnostics := optimizer.Optimize(program, optimizer.Options{
+		Strict: interp.env.Strict(),
+		Bound: func(name string) bool {
+			_, ok := interp.env.Get(name)
+			return ok
+		},
+	})
+	if diag.HasErrors(diagnostics) {
+		return nil, &ParseError{Diagnostics: diagnostics}
+	}
+	bytecode, err := compiler.Compile(program)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{interp: interp, bytecode: bytecode}, nil
+}
+
+// Eval run program with bindings on top of interpreter globals.
+// Program's let statements are discarded after run, so evaluations are independent
+// and can run concurrently while interpreter isn't changed
+func (p *Program) Eval(ctx context.Context, bindings map[string]any) (Value, error) {
+	env := p.interp.env.SubEnv()
+	for name, value := range bindings {
+		obj, err := ToObject(value)
+		if err != nil {
+			return Value{}, err
+		}
+		env.Set(name, obj)
+	}
+	machine := vm.New(p.bytecode, env)
+	if err := machine.RunContext(ctx, p.interp.limits); err != nil {
+		return Value{}, err
+	}
+	return result(ctx, machine.Result())
+}
+
+// result turn error object into Go error
+func result(ctx context.Context, obj object.Object) (Value, error) {
+	err, ok := obj.(*object.Error)
+	if !ok {
+		return Value{obj: obj}, nil
+	}
+	if object.IsLimitError(err) && ctx.Err() != nil {
+		return Value{}, &cancelError{err: err, cause: ctx.Err()}
+	}
+	return Value{}, err
+}
+
+// cancelError is limit error caused by context, it matches both
+type cancelError struct {
+	err   *object.Error
+	cause error
+}
+
+func (err *cancelError) Error() string   { return err.err.Error() }
+func (err *cancelError) Unwrap() []error { return []error{err.err, err.cause} }
+
+func parse(src string) (*ast.Program, error) {
+	p := parser.New(lexer.New(src))
+	program := p.Parse()
+	if p.HasErrors() {
+		return nil, &ParseError{Diagnostics: p.Diagnostics()}
+	}
+	return program, nil
+}
+
+// ParseError is a list of errors found in source before evaluation
+type ParseError struct {
+	Diagnostics []diag.Diagnostic
+}
+
+func (err *ParseError) Error() string {
+	messages := make([]string, 0, len(err.Diagnostics))
+	for _, d := range err.Diagnostics {
+		if d.Severity == diag.Error {
+			messages = append(messages, d.Error())
+		}
+	}
+	return strings.Join(messages, "\n")
+}
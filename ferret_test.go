@@ -0,0 +1,207 @@
+package ferret_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Richtermnd/ferret"
+	"github.com/Richtermnd/ferret/object"
+)
+
+func TestEval(t *testing.T) {
+	interp := ferret.New()
+	ctx := context.Background()
+	if err := interp.Set("price", 200); err != nil {
+		t.Fatal(err)
+	}
+	v, err := interp.Eval(ctx, "let total = price * (1 + 20%)\ntotal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := v.Float(); !ok || f != 240 {
+		t.Errorf("expected 240 got %s", v)
+	}
+	// let statements of Eval stay in interpreter
+	total, ok := interp.Get("total")
+	if !ok || total.Interface() != 240.0 {
+		t.Errorf("expected total = 240 got %s", total)
+	}
+	v, err = interp.Eval(ctx, "let x = 1")
+	if err != nil || !v.IsNil() {
+		t.Errorf("expected no value got %s, %v", v, err)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	interp := ferret.New()
+	ctx := context.Background()
+
+	_, err := interp.Eval(ctx, "1 +")
+	var parseErr *ferret.ParseError
+	if !errors.As(err, &parseErr) || len(parseErr.Diagnostics) != 1 {
+		t.Errorf("expected parse error got %v", err)
+	}
+
+	_, err = interp.Eval(ctx, "unknown + 1")
+	var objErr *object.Error
+	if !errors.As(err, &objErr) || objErr.ErrType != object.NOT_FOUND_ERR {
+		t.Errorf("expected not found error got %v", err)
+	}
+
+	// the first runtime error is returned even if it's not the last statement
+	v, err := interp.Eval(ctx, "let a = x\n1")
+	if !errors.As(err, &objErr) || objErr.ErrType != object.NOT_FOUND_ERR {
+		t.Errorf("expected not found error got %s, %v", v, err)
+	}
+	program, err := interp.Compile("1 / n\n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = program.Eval(ctx, map[string]any{"n": 0})
+	if !errors.As(err, &objErr) || objErr.ErrType != object.ZERO_DIVISION_ERR {
+		t.Errorf("expected zero division error got %s, %v", v, err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	interp := ferret.New()
+	interp.RegisterFunc("zeros", func(n int) []float64 { return make([]float64, n) })
+	interp.SetLimits(ferret.Limits{MaxSteps: 100, MaxAlloc: 10})
+	program, err := interp.Compile("zeros(n)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	testCases := []struct {
+		desc string
+		eval func() (ferret.Value, error)
+		ctx  error
+	}{
+		{desc: "steps", eval: func() (ferret.Value, error) {
+			return interp.Eval(ctx, strings.Repeat("1 + ", 100)+"1")
+		}},
+		{desc: "alloc", eval: func() (ferret.Value, error) {
+			return interp.Eval(ctx, "zeros(11)")
+		}},
+		{desc: "cancel", ctx: context.Canceled, eval: func() (ferret.Value, error) {
+			return interp.Eval(cancelled, "1")
+		}},
+		{desc: "program alloc", eval: func() (ferret.Value, error) {
+			return program.Eval(ctx, map[string]any{"n": 11})
+		}},
+		{desc: "compiled factorial", eval: func() (ferret.Value, error) {
+			// it isn't computed by compilation without limits
+			program, err := interp.Compile("3000000!")
+			if err != nil {
+				return ferret.Value{}, err
+			}
+			return program.Eval(ctx, nil)
+		}},
+		{desc: "program cancel", ctx: context.Canceled, eval: func() (ferret.Value, error) {
+			return program.Eval(cancelled, map[string]any{"n": 1})
+		}},
+	}
+	for _, tt := range testCases {
+		_, err := tt.eval()
+		var objErr *object.Error
+		if !errors.As(err, &objErr) || !object.IsLimitError(objErr) {
+			t.Errorf("%s: expected limit error got %v", tt.desc, err)
+		}
+		if tt.ctx != nil && !errors.Is(err, tt.ctx) {
+			t.Errorf("%s: expected %v got %v", tt.desc, tt.ctx, err)
+		}
+	}
+	if v, err := program.Eval(ctx, map[string]any{"n": 10}); err != nil {
+		t.Errorf("unexpected error: %v, %s", err, v)
+	}
+}
+
+func TestSet(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	testCases := []struct {
+		value    any
+		expected string
+	}{
+		{value: 5, expected: "5"},
+		{value: int8(-5), expected: "-5"},
+		{value: uint64(1 << 63), expected: "9223372036854775808"},
+		{value: 2.5, expected: "2.500000"},
+		{value: float32(0.5), expected: "0.500000"},
+		{value: true, expected: "true"},
+		{value: huge, expected: "100000000000000000000"},
+		{value: big.NewInt(7), expected: "7"},
+		{value: &object.Integer{Value: 3}, expected: "3"},
+		{value: []float64{1, 2}, expected: "[1.000000, 2.000000]"},
+		{value: [][]int{{1}, {}}, expected: "[[1], []]"},
+		{value: []any{1, true}, expected: "[1, true]"},
+	}
+	interp := ferret.New()
+	for _, tt := range testCases {
+		if err := interp.Set("x", tt.value); err != nil {
+			t.Errorf("%T: %v", tt.value, err)
+			continue
+		}
+		if v, _ := interp.Get("x"); v.String() != tt.expected {
+			t.Errorf("%T: expected %s got %s", tt.value, tt.expected, v)
+		}
+	}
+	if err := interp.Set("x", "string"); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+	if _, ok := interp.Get("y"); ok {
+		t.Errorf("y is not set")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	interp := ferret.New()
+	ctx := context.Background()
+	interp.Set("rate", 0.5)
+	program, err := interp.Compile("let k = 2\nk * amount * rate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, amount := range []int{1, 10, 100} {
+		v, err := program.Eval(ctx, map[string]any{"amount": amount})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f, ok := v.Float(); !ok || f != float64(amount) {
+			t.Errorf("amount = %d: expected %d got %s", amount, amount, v)
+		}
+	}
+	// program runs don't change interpreter
+	if _, ok := interp.Get("k"); ok {
+		t.Errorf("let of program leaked to interpreter")
+	}
+	if _, err := program.Eval(ctx, nil); err == nil {
+		t.Errorf("expected error without amount")
+	}
+	if _, err := program.Eval(ctx, map[string]any{"amount": "string"}); err == nil {
+		t.Errorf("expected error for unsupported binding")
+	}
+	if _, err := interp.Compile("let = 1"); err == nil {
+		t.Errorf("expected parse error")
+	}
+	var parseErr *ferret.ParseError
+	if _, err := interp.Compile("amount + 1 / (2 - 2)"); !errors.As(err, &parseErr) || err.Error() != "1:10: division by zero: 1 / 0" {
+		t.Errorf("expected division by zero got %v", err)
+	}
+}
+
+func TestCompileBuiltins(t *testing.T) {
+	interp := ferret.New()
+	interp.RegisterFunc("sqrt", func(x float64) float64 { return -x })
+	program, err := interp.Compile("sqrt(4) + abs(-1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := program.Eval(context.Background(), nil); err != nil || v.String() != "-3.000000" {
+		t.Errorf("expected -3 got %s, %v", v, err)
+	}
+}
@@ -0,0 +1,450 @@
+// Package format implements canonical formatting of ferret source code.
+//
+// Formatting is idempotent: Source(Source(x)) == Source(x).
+// Parentheses are placed only where precedence requires them,
+// every statement takes its own line and blocks are indented with 4 spaces.
+// Comments are kept: comments between statements stay on their lines,
+// block comments inside expressions stay in place and line comments
+// inside expressions are moved to the end of line
+package format
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/token"
+)
+
+const indent = "    "
+
+// Source format source code, return parse errors if it's invalid
+func Source(src []byte) ([]byte, error) {
+	p := parser.New(lexer.New(string(src)))
+	program := p.Parse()
+	if p.HasErrors() {
+		return nil, errors.Join(p.Errors()...)
+	}
+	return []byte(Node(program)), nil
+}
+
+// Node return canonical representation of node
+func Node(node ast.Node) string {
+	pr := &printer{lineStart: true, printed: make(map[*token.Trivia]bool)}
+	pr.node(node)
+	pr.flush()
+	return pr.sb.String()
+}
+
+type printer struct {
+	sb    strings.Builder
+	level int
+
+	// lineStart true if nothing is written on current line yet, indent is written lazily
+	lineStart bool
+
+	// printed is trivia that is already written, ast nodes can share tokens
+	printed map[*token.Trivia]bool
+
+	// pending is line comments from inside of expression, they are written at the end of line
+	pending []string
+}
+
+func (pr *printer) write(s string) {
+	if pr.lineStart && s != "" {
+		pr.sb.WriteString(strings.Repeat(indent, pr.level))
+		pr.lineStart = false
+	}
+	pr.sb.WriteString(s)
+}
+
+func (pr *printer) newline() {
+	pr.flush()
+	pr.sb.WriteString("\n")
+	pr.lineStart = true
+}
+
+func (pr *printer) flush() {
+	for _, text := range pr.pending {
+		pr.write(" " + text)
+	}
+	pr.pending = nil
+}
+
+// take return comments of token that aren't written yet
+func (pr *printer) take(tok token.Token) []token.Comment {
+	if tok.Trivia == nil || pr.printed[tok.Trivia] {
+		return nil
+	}
+	pr.printed[tok.Trivia] = true
+	return tok.Trivia.Comments
+}
+
+// leading write comments before statement: the ones that were on the same line
+// as previous statement stay there, others take their own lines
+func (pr *printer) leading(comments []token.Comment) {
+	for _, comment := range comments {
+		if !comment.OwnLine && !pr.lineStart {
+			pr.write(" " + comment.Text)
+			continue
+		}
+		if !pr.lineStart {
+			pr.newline()
+		}
+		pr.write(comment.Text)
+		pr.newline()
+	}
+	if !pr.lineStart {
+		pr.newline()
+	}
+}
+
+// inline write comments inside of expression, line comments are moved to the end of line
+func (pr *printer) inline(tok token.Token) {
+	for _, comment := range pr.take(tok) {
+		if strings.HasPrefix(comment.Text, "#") {
+			pr.pending = append(pr.pending, comment.Text)
+			continue
+		}
+		pr.write(comment.Text + " ")
+	}
+}
+
+func hasBlockComment(tok token.Token) bool {
+	if tok.Trivia == nil {
+		return false
+	}
+	for _, comment := range tok.Trivia.Comments {
+		if strings.HasPrefix(comment.Text, "/*") {
+			return true
+		}
+	}
+	return false
+}
+
+func (pr *printer) node(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		pr.statements(node.Statements, node.End)
+	case ast.Statement:
+		pr.statement(node)
+	case ast.Expression:
+		pr.expression(node)
+	}
+}
+
+// statements write every statement on its own line, end is a token after them
+func (pr *printer) statements(stmts []ast.Statement, end token.Token) {
+	for i, stmt := range stmts {
+		pr.leading(pr.take(statementToken(stmt)))
+		pr.statement(stmt)
+		pr.separator(stmts, i)
+	}
+	pr.leading(pr.take(end))
+}
+
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.FunctionStatement:
+		return stmt.Token
+	case *ast.ImportStatement:
+		return stmt.Token
+	case *ast.PragmaStatement:
+		return stmt.Token
+	case *ast.BlockStatement:
+		return stmt.Token
+	}
+	return token.Token{}
+}
+
+func (pr *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		pr.expression(stmt.Expr)
+	case *ast.LetStatement:
+		pr.write("let ")
+		pr.inline(stmt.Name.Token)
+		pr.write(stmt.Name.Value)
+		if stmt.Type != nil {
+			pr.write(": ")
+			pr.typeName(stmt.Type)
+		}
+		pr.write(" = ")
+		pr.expression(stmt.Value)
+	case *ast.FunctionStatement:
+		pr.function(stmt)
+	case *ast.ImportStatement:
+		pr.write("import ")
+		pr.inline(stmt.Path)
+		pr.write(strconv.Quote(stmt.Path.Literal))
+		pr.write(" as ")
+		pr.inline(stmt.Alias.Token)
+		pr.write(stmt.Alias.Value)
+	case *ast.PragmaStatement:
+		pr.write("#")
+		pr.write(stmt.Name)
+	case *ast.BlockStatement:
+		pr.block(stmt)
+	default:
+		pr.write(stmt.String())
+	}
+}
+
+// function write declaration: fn area(r: float) -> float { ... }
+func (pr *printer) function(fn *ast.FunctionStatement) {
+	pr.write("fn ")
+	pr.inline(fn.Name.Token)
+	pr.write(fn.Name.Value)
+	pr.write("(")
+	for i, param := range fn.Parameters {
+		if i > 0 {
+			pr.write(", ")
+		}
+		pr.inline(param.Name.Token)
+		pr.write(param.Name.Value)
+		if param.Type != nil {
+			pr.write(": ")
+			pr.typeName(param.Type)
+		}
+	}
+	pr.write(")")
+	if fn.ReturnType != nil {
+		pr.write(" -> ")
+		pr.typeName(fn.ReturnType)
+	}
+	pr.write(" ")
+	pr.inline(fn.Body.Token)
+	pr.block(fn.Body)
+}
+
+func (pr *printer) typeName(typ *ast.TypeName) {
+	pr.inline(typ.Token)
+	pr.write(typ.Name)
+}
+
+func (pr *printer) block(block *ast.BlockStatement) {
+	if len(block.Statements) == 0 && block.End.Trivia == nil {
+		pr.write("{}")
+		return
+	}
+	pr.write("{")
+	pr.level++
+	pr.statements(block.Statements, block.End)
+	pr.level--
+	pr.write("}")
+}
+
+// separator write ';' after i-th statement if the next one starts with '-',
+// otherwise they would be joined into one: a \n -b is a - b
+func (pr *printer) separator(stmts []ast.Statement, i int) {
+	if i+1 == len(stmts) {
+		return
+	}
+	switch stmts[i].(type) {
+	case *ast.ExpressionStatement, *ast.LetStatement:
+	default:
+		return
+	}
+	if next, ok := stmts[i+1].(*ast.ExpressionStatement); ok && startsWithMinus(next.Expr) {
+		pr.write(";")
+	}
+}
+
+// startsWithMinus report if printed expression starts with unary minus
+func startsWithMinus(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.PrefixExpression:
+		return expr.Operator == "-"
+	case *ast.InfixExpression:
+		if expr.Token.Is(token.POW) {
+			return precedence(expr.Left) > token.POWER && startsWithMinus(expr.Left)
+		}
+		return precedence(expr.Left) >= expr.Token.Precedence() && startsWithMinus(expr.Left)
+	case *ast.ComparisonChain:
+		return precedence(expr.Operands[0]) > expr.Token.Precedence() && startsWithMinus(expr.Operands[0])
+	case *ast.PostfixExpression:
+		return precedence(expr.Left) >= token.POSTFIX && startsWithMinus(expr.Left)
+	case *ast.CallExpression:
+		if expr.Token.Is(token.PIPE) {
+			return startsWithMinus(expr.Arguments[expr.Piped[0]])
+		}
+		return precedence(expr.Function) >= token.CALL && startsWithMinus(expr.Function)
+	case *ast.SelectorExpression:
+		return precedence(expr.X) >= token.CALL && startsWithMinus(expr.X)
+	}
+	return false
+}
+
+// precedence of expression as operand
+func precedence(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		return expr.Token.Precedence()
+	case *ast.ComparisonChain:
+		return expr.Token.Precedence()
+	case *ast.PrefixExpression:
+		return token.UNARY
+	case *ast.PostfixExpression:
+		return token.POSTFIX
+	case *ast.CallExpression:
+		if expr.Token.Is(token.PIPE) {
+			return expr.Token.Precedence()
+		}
+		return token.CALL
+	case *ast.SelectorExpression:
+		return token.CALL
+	}
+	return token.HIGHEST
+}
+
+// operand print expression in parentheses if its precedence is lower than min
+func (pr *printer) operand(expr ast.Expression, min int) {
+	if precedence(expr) < min {
+		pr.write("(")
+		pr.expression(expr)
+		pr.write(")")
+		return
+	}
+	pr.expression(expr)
+}
+
+func (pr *printer) expression(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		pr.infix(expr)
+	case *ast.ComparisonChain:
+		for i, operand := range expr.Operands {
+			if i > 0 {
+				pr.write(" ")
+				pr.inline(expr.Operators[i-1])
+				pr.write(expr.Operators[i-1].Literal + " ")
+			}
+			// nested comparison would become a part of the chain
+			pr.operand(operand, expr.Token.Precedence()+1)
+		}
+	case *ast.PrefixExpression:
+		pr.inline(expr.Token)
+		pr.write(expr.Operator)
+		pr.operand(expr.Right, token.UNARY)
+	case *ast.PostfixExpression:
+		pr.operand(expr.Left, token.POSTFIX)
+		if hasBlockComment(expr.Token) {
+			pr.write(" ")
+		}
+		pr.inline(expr.Token)
+		pr.write(expr.Operator)
+	case *ast.CallExpression:
+		if expr.Token.Is(token.PIPE) {
+			pr.pipe(expr)
+			return
+		}
+		pr.operand(expr.Function, token.CALL)
+		pr.arguments(expr.Arguments)
+	case *ast.SelectorExpression:
+		pr.selector(expr)
+	case *ast.Identifier:
+		pr.inline(expr.Token)
+		pr.write(expr.Value)
+	case *ast.IntegerLiteral:
+		pr.inline(expr.Token)
+		pr.write(expr.String())
+	case *ast.FloatLiteral:
+		pr.inline(expr.Token)
+		pr.write(expr.String())
+	case *ast.BooleanLiteral:
+		pr.inline(expr.Token)
+		pr.write(expr.String())
+	case *ast.VectorLiteral:
+		pr.inline(expr.Token)
+		pr.list("[", expr.Elements, "]")
+	default:
+		pr.write(expr.String())
+	}
+}
+
+// selector write x.name, number needs parentheses: 1.x would be a float
+func (pr *printer) selector(expr *ast.SelectorExpression) {
+	switch expr.X.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral:
+		pr.write("(")
+		pr.expression(expr.X)
+		pr.write(")")
+	default:
+		pr.operand(expr.X, token.CALL)
+	}
+	pr.inline(expr.Token)
+	pr.write(".")
+	pr.inline(expr.Sel.Token)
+	pr.write(expr.Sel.Value)
+}
+
+func (pr *printer) infix(expr *ast.InfixExpression) {
+	prec := expr.Token.Precedence()
+	// operators are left associative, so right operand with the same precedence needs parentheses,
+	// comparisons need them on both sides to not become a chain, '^' is right associative
+	leftMin, rightMin := prec, prec+1
+	switch {
+	case expr.Token.IsComparison():
+		leftMin = prec + 1
+	case expr.Token.Is(token.POW):
+		leftMin, rightMin = prec+1, prec
+	}
+	pr.operand(expr.Left, leftMin)
+	pr.write(" ")
+	pr.inline(expr.Token)
+	pr.write(expr.Operator + " ")
+	// '%' followed by '-' or '!' is a percent: 7 % (-2) isn't (7%) - 2
+	if _, ok := expr.Right.(*ast.PrefixExpression); ok && expr.Token.Is(token.REM) {
+		pr.write("(")
+		pr.expression(expr.Right)
+		pr.write(")")
+		return
+	}
+	pr.operand(expr.Right, rightMin)
+}
+
+// pipe print call that came from pipeline: x |> f, x |> f(2), x |> f(2, _)
+func (pr *printer) pipe(call *ast.CallExpression) {
+	prec := call.Token.Precedence()
+	pr.operand(call.Arguments[call.Piped[0]], prec)
+	pr.write(" ")
+	pr.inline(call.Token)
+	pr.write("|> ")
+	pr.operand(call.Function, token.CALL)
+
+	if len(call.Piped) == 1 && call.Piped[0] == 0 {
+		if len(call.Arguments) > 1 {
+			pr.arguments(call.Arguments[1:])
+		}
+		return
+	}
+	args := make([]ast.Expression, len(call.Arguments))
+	copy(args, call.Arguments)
+	for _, i := range call.Piped {
+		args[i] = &ast.Identifier{Value: "_"}
+	}
+	pr.arguments(args)
+}
+
+func (pr *printer) arguments(args []ast.Expression) {
+	pr.list("(", args, ")")
+}
+
+// list print comma separated expressions between open and close
+func (pr *printer) list(open string, exprs []ast.Expression, close string) {
+	pr.write(open)
+	for i, expr := range exprs {
+		if i > 0 {
+			pr.write(", ")
+		}
+		pr.expression(expr)
+	}
+	pr.write(close)
+}
@@ -0,0 +1,189 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/Richtermnd/ferret/format"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/parser"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		source   string
+		expected string
+	}{
+		{
+			desc:     "spacing",
+			source:   "1+2*  3",
+			expected: "1 + 2 * 3\n",
+		},
+		{
+			desc:     "redundant parens",
+			source:   "(1 + (2 * 3))",
+			expected: "1 + 2 * 3\n",
+		},
+		{
+			desc:     "required parens",
+			source:   "(1 + 2) * 3",
+			expected: "(1 + 2) * 3\n",
+		},
+		{
+			desc:     "left associativity",
+			source:   "(1 - 2) - (3 - 4)",
+			expected: "1 - 2 - (3 - 4)\n",
+		},
+		{
+			desc:     "prefix",
+			source:   "-(2) + -(2 + 1) + !(true)",
+			expected: "-2 + -(2 + 1) + !true\n",
+		},
+		{
+			desc:     "postfix",
+			source:   "(-3)! + (3)! + 50%",
+			expected: "(-3)! + 3! + 50%\n",
+		},
+		{
+			desc:     "percent before minus",
+			source:   "(50%) - 1 + (1 + 2%) - 3",
+			expected: "50% - 1 + (1 + 2%) - 3\n",
+		},
+		{
+			desc:     "remainder by prefix",
+			source:   "7 % (-2) + 7 % (!x) + 7 % (2)",
+			expected: "7 % (-2) + 7 % (!x) + 7 % 2\n",
+		},
+		{
+			desc:     "implicit multiplication",
+			source:   "2x + 3(x + 1)",
+			expected: "2 * x + 3 * (x + 1)\n",
+		},
+		{
+			desc:     "power",
+			source:   "(2^3)^2 + 2^(3^2) + (-2)^2 + -(2^2) + e^(2x) + (x^2)!",
+			expected: "(2 ^ 3) ^ 2 + 2 ^ 3 ^ 2 + (-2) ^ 2 + -2 ^ 2 + e ^ (2 * x) + (x ^ 2)!\n",
+		},
+		{
+			desc:     "vector",
+			source:   "[1,2 ,(3)] * [[1,2],[ 3,4 ]]",
+			expected: "[1, 2, 3] * [[1, 2], [3, 4]]\n",
+		},
+		{
+			desc:     "annotations",
+			source:   "let rate:float=0.05\nfn  area( r:float ,n )->float{3*r*r}",
+			expected: "let rate: float = 0.05\nfn area(r: float, n) -> float {\n    3 * r * r\n}\n",
+		},
+		{
+			desc:     "imports",
+			source:   "import   \"lib/stats.fe\"as s;s.mean( [1,2] )+(1).x*(a+b).c",
+			expected: "import \"lib/stats.fe\" as s\ns.mean([1, 2]) + (1).x * (a + b).c\n",
+		},
+		{
+			desc:     "comparison",
+			source:   "(a < b) == (c < d)",
+			expected: "(a < b) == (c < d)\n",
+		},
+		{
+			desc:     "comparison chain",
+			source:   "0<x   <= (1 + 2)",
+			expected: "0 < x <= 1 + 2\n",
+		},
+		{
+			desc:     "calls",
+			source:   "max( 1,2 ,abs(-3) )",
+			expected: "max(1, 2, abs(-3))\n",
+		},
+		{
+			desc:     "pipes",
+			source:   "x|>abs |>pow(2)|>max(1, _)",
+			expected: "x |> abs |> pow(2) |> max(1, _)\n",
+		},
+		{
+			desc:     "pipe in call",
+			source:   "abs(x |> sin)",
+			expected: "abs(x |> sin)\n",
+		},
+		{
+			desc:     "statements",
+			source:   "let a = 1; let b=2;a+b",
+			expected: "let a = 1\nlet b = 2\na + b\n",
+		},
+		{
+			desc:     "statement starting with minus",
+			source:   "a; -b",
+			expected: "a;\n-b\n",
+		},
+		{
+			desc:     "block",
+			source:   "{let a = 1 {a}} {}",
+			expected: "{\n    let a = 1\n    {\n        a\n    }\n}\n{}\n",
+		},
+		{
+			desc:     "comments between statements",
+			source:   "# header\nlet a = 1  # one\n\n  /* block */ a\n# footer",
+			expected: "# header\nlet a = 1 # one\n/* block */\na\n# footer\n",
+		},
+		{
+			desc:     "comments in expression",
+			source:   "1 + # one\n /* two */ 2 * x",
+			expected: "1 + /* two */ 2 * x # one\n",
+		},
+		{
+			desc:     "comments in parens",
+			source:   "f( /* a */ x, /* b */ (y))",
+			expected: "f(/* a */ x, /* b */ y)\n",
+		},
+		{
+			desc:     "comments in block",
+			source:   "{ # start\n a # a\n # end\n}\n{ /* empty */ }",
+			expected: "{ # start\n    a # a\n    # end\n}\n{ /* empty */\n}\n",
+		},
+		{
+			desc:     "pragma",
+			source:   "#strict 1",
+			expected: "#strict\n1\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			formatted, err := format.Source([]byte(tC.source))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(formatted) != tC.expected {
+				t.Errorf("expected %q, got %q", tC.expected, formatted)
+			}
+
+			// formatting is idempotent
+			again, err := format.Source(formatted)
+			if err != nil {
+				t.Fatalf("formatted source has error: %v", err)
+			}
+			if string(again) != string(formatted) {
+				t.Errorf("not idempotent: %q formatted to %q", formatted, again)
+			}
+
+			// formatting doesn't change meaning
+			if expected, got := parse(t, tC.source), parse(t, string(formatted)); expected != got {
+				t.Errorf("meaning changed: expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	if _, err := format.Source([]byte("1 +")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func parse(t *testing.T, source string) string {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.Parse()
+	if p.HasErrors() {
+		t.Fatalf("parse errors: %v", p.Errors())
+	}
+	return program.String()
+}
@@ -0,0 +1,222 @@
+package ferret
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Richtermnd/ferret/object"
+)
+
+var (
+	objectType = reflect.TypeFor[object.Object]()
+	errorType  = reflect.TypeFor[error]()
+)
+
+// RegisterFunc bind Go function to name, so scripts can call it.
+// Parameters and the result can be of types supported by ToObject,
+// the function may return error as the second result.
+// Wrong number or types of arguments, returned errors and panics are ferret errors at call site
+func (interp *Interpreter) RegisterFunc(name string, fn any) error {
+	builtin, err := wrapFunc(name, fn)
+	if err != nil {
+		return err
+	}
+	interp.env.Set(name, builtin)
+	return nil
+}
+
+func wrapFunc(name string, fn any) (*object.Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("%s: expected function got %T", name, fn)
+	}
+	t := v.Type()
+	for i := range t.NumIn() {
+		in := t.In(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		if !supported(in) {
+			return nil, fmt.Errorf("%s: unsupported type of argument %d: %s", name, i+1, t.In(i))
+		}
+	}
+	switch {
+	case t.NumOut() == 1 && supported(t.Out(0)):
+	case t.NumOut() == 2 && supported(t.Out(0)) && t.Out(1) == errorType:
+	default:
+		return nil, fmt.Errorf("%s: function must return a value and optionally an error, got %s", name, t)
+	}
+	return &object.Builtin{Name: name, Fn: call(name, v), Strict: strictArgs(name, t)}, nil
+}
+
+// strictArgs check arguments in strict mode against parameters of fn type:
+// bools are only for bool parameters and bool parameters take only bools
+func strictArgs(name string, t reflect.Type) func(args []object.Object) object.Object {
+	return func(args []object.Object) object.Object {
+		for i, arg := range args {
+			if i >= t.NumIn() && !t.IsVariadic() {
+				// wrong number of arguments is reported by call
+				break
+			}
+			param := paramType(t, i).Kind()
+			_, isBool := arg.(*object.Bool)
+			switch {
+			case param == reflect.Interface || object.IsError(arg):
+			case isBool && param != reflect.Bool:
+				return object.NewError(object.TYPE_ERR, "builtin %s: argument %d: bool is not a number", name, i+1)
+			case !isBool && param == reflect.Bool:
+				return object.NewError(object.TYPE_ERR, "builtin %s: argument %d: expected bool got %s", name, i+1, describe(arg))
+			}
+		}
+		return nil
+	}
+}
+
+// paramType return type of parameter for i-th argument, variadic ones included
+func paramType(t reflect.Type, i int) reflect.Type {
+	n := t.NumIn()
+	if t.IsVariadic() && i >= n-1 {
+		return t.In(n - 1).Elem()
+	}
+	return t.In(i)
+}
+
+// supported report if values of type can be converted from and to objects
+func supported(t reflect.Type) bool {
+	if t == objectType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice, reflect.Array:
+		return supported(t.Elem())
+	}
+	return false
+}
+
+func call(name string, fn reflect.Value) object.BuiltinFunction {
+	t := fn.Type()
+	return func(args ...object.Object) (res object.Object) {
+		n := t.NumIn()
+		switch {
+		case t.IsVariadic() && len(args) < n-1:
+			return object.NewError(object.ARGUMENTS_ERR, "%s: expected at least %d arguments got %d", name, n-1, len(args))
+		case !t.IsVariadic() && len(args) != n:
+			return object.NewError(object.ARGUMENTS_ERR, "%s: expected %d arguments got %d", name, n, len(args))
+		}
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			if object.IsError(arg) {
+				return arg
+			}
+			argType := paramType(t, i)
+			v, ok := fromObject(arg, argType)
+			if !ok {
+				return object.NewError(object.TYPE_ERR, "%s: argument %d: expected %s got %s", name, i+1, argType, describe(arg))
+			}
+			in[i] = v
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				res = object.NewError(object.FUNCTION_ERR, "%s: panic: %v", name, r)
+			}
+		}()
+		out := fn.Call(in)
+		if len(out) == 2 && !out[1].IsNil() {
+			return object.NewError(object.FUNCTION_ERR, "%s: %v", name, out[1].Interface())
+		}
+		obj, err := toObject(out[0])
+		if err != nil {
+			return object.NewError(object.FUNCTION_ERR, "%s: %v", name, err)
+		}
+		return obj
+	}
+}
+
+// describe object type for type errors, vectors with their shape
+func describe(obj object.Object) string {
+	if vec, ok := obj.(*object.Vector); ok {
+		if len(vec.Elements) > 0 {
+			return fmt.Sprintf("%s[%d] of %s", vec.Type(), len(vec.Elements), describe(vec.Elements[0]))
+		}
+		return fmt.Sprintf("%s[0]", vec.Type())
+	}
+	return string(obj.Type())
+}
+
+// fromObject convert object to value of Go type, numbers convert like in builtins
+// (bools are 0 and 1), integers must fit in type
+func fromObject(obj object.Object, t reflect.Type) (reflect.Value, bool) {
+	if t == objectType {
+		return reflect.ValueOf(&obj).Elem(), true
+	}
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		switch obj := obj.(type) {
+		case *object.Bool:
+			v.SetBool(obj.Value)
+		case object.Booler:
+			v.SetBool(obj.AsBool().Value)
+		default:
+			return v, false
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := asInt(obj)
+		if !ok || v.OverflowInt(i) {
+			return v, false
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if b, ok := obj.(*object.BigInt); ok && b.Value.IsUint64() && !v.OverflowUint(b.Value.Uint64()) {
+			v.SetUint(b.Value.Uint64())
+			break
+		}
+		i, ok := asInt(obj)
+		if !ok || i < 0 || v.OverflowUint(uint64(i)) {
+			return v, false
+		}
+		v.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, ok := object.AsNative(obj)
+		if !ok {
+			return v, false
+		}
+		v.SetFloat(f)
+	case reflect.Slice, reflect.Array:
+		vec, ok := obj.(*object.Vector)
+		if !ok {
+			return v, false
+		}
+		if t.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(t, len(vec.Elements), len(vec.Elements)))
+		} else if t.Len() != len(vec.Elements) {
+			return v, false
+		}
+		for i, el := range vec.Elements {
+			elem, ok := fromObject(el, t.Elem())
+			if !ok {
+				return v, false
+			}
+			v.Index(i).Set(elem)
+		}
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+func asInt(obj object.Object) (int64, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value, true
+	case *object.Bool:
+		return obj.AsInt().Value, true
+	}
+	return 0, false
+}
@@ -0,0 +1,151 @@
+package ferret_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Richtermnd/ferret"
+	"github.com/Richtermnd/ferret/object"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	interp := ferret.New()
+	funcs := map[string]any{
+		"tax":  func(amount, rate float64) float64 { return amount * rate },
+		"inc":  func(x int8) int8 { return x + 1 },
+		"half": func(x uint) uint { return x / 2 },
+		"neg":  func(b bool) bool { return !b },
+		"sum": func(xs ...float64) float64 {
+			s := 0.0
+			for _, x := range xs {
+				s += x
+			}
+			return s
+		},
+		"vec": func(n int) []int { return make([]int, n) },
+		"len": func(xs []float64) int { return len(xs) },
+		"eye": func(n int) [][]float64 {
+			m := make([][]float64, n)
+			for i := range m {
+				m[i] = make([]float64, n)
+				m[i][i] = 1
+			}
+			return m
+		},
+		"trace": func(m [][]float64) float64 {
+			s := 0.0
+			for i := range m {
+				s += m[i][i]
+			}
+			return s
+		},
+		"pair": func() [2]bool { return [2]bool{true, false} },
+		"id":   func(obj object.Object) object.Object { return obj },
+		"safediv": func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"boom": func() int { panic("boom") },
+	}
+	for name, fn := range funcs {
+		if err := interp.RegisterFunc(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{source: "tax(200, 15%)", expected: "30.000000"},
+		{source: "200 |> tax(_, 0.5)", expected: "100.000000"},
+		{source: "inc(126)", expected: "127"},
+		{source: "half(7)", expected: "3"},
+		{source: "neg(1 > 2)", expected: "true"},
+		{source: "sum()", expected: "0.000000"},
+		{source: "sum(1, 2, true)", expected: "4.000000"},
+		{source: "vec(3)", expected: "[0, 0, 0]"},
+		{source: "len(vec(4))", expected: "4"},
+		{source: "eye(2)", expected: "[[1.000000, 0.000000], [0.000000, 1.000000]]"},
+		{source: "trace(eye(3))", expected: "3.000000"},
+		{source: "pair()", expected: "[true, false]"},
+		{source: "id(2.5)", expected: "2.500000"},
+		{source: "safediv(1, 4)", expected: "0.250000"},
+
+		{source: "tax(1)", expected: "wrong arguments: tax: expected 2 arguments got 1"},
+		{source: "inc(200)", expected: "type error: inc: argument 1: expected int8 got INTEGER"},
+		{source: "inc(1.5)", expected: "type error: inc: argument 1: expected int8 got FLOAT"},
+		{source: "half(-1)", expected: "type error: half: argument 1: expected uint got INTEGER"},
+		{source: "neg(1)", expected: "false"},
+		{source: "neg(vec(1))", expected: "type error: neg: argument 1: expected bool got VECTOR[1] of INTEGER"},
+		{source: "len(5)", expected: "type error: len: argument 1: expected []float64 got INTEGER"},
+		{source: "trace(pair())", expected: "type error: trace: argument 1: expected [][]float64 got VECTOR[2] of BOOL"},
+		{source: "tax(1, unknown)", expected: "not found: unknown"},
+		{source: "safediv(1, 0)", expected: "function error: safediv: division by zero"},
+		{source: "boom()", expected: "function error: boom: panic: boom"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			v, err := interp.Eval(context.Background(), tt.source)
+			var res string
+			if err != nil {
+				res = err.Error()
+			} else {
+				res = v.String()
+			}
+			if res != tt.expected {
+				t.Errorf("expected %q got %q", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestRegisterFuncErrors(t *testing.T) {
+	testCases := []any{
+		5,
+		func() {},
+		func(s string) int { return 0 },
+		func() string { return "" },
+		func() (int, int) { return 0, 0 },
+		func() map[int]int { return nil },
+	}
+	interp := ferret.New()
+	for _, fn := range testCases {
+		if err := interp.RegisterFunc("f", fn); err == nil {
+			t.Errorf("%T: expected error", fn)
+		}
+	}
+}
+
+func TestStrictFunc(t *testing.T) {
+	interp := ferret.New()
+	interp.SetStrict(true)
+	interp.RegisterFunc("double", func(x float64) float64 { return 2 * x })
+	interp.RegisterFunc("not", func(b bool) bool { return !b })
+	interp.RegisterFunc("all", func(bs ...bool) bool { return !slices.Contains(bs, false) })
+	for _, src := range []string{"double(true)", "not(1)", "all(true, 1)"} {
+		_, err := interp.Eval(context.Background(), src)
+		if err == nil || !strings.HasPrefix(err.Error(), object.TYPE_ERR) {
+			t.Errorf("%s: expected type error got %v", src, err)
+		}
+	}
+	for _, src := range []string{"not(true)", "all(true, 1 < 2)", "double(2)"} {
+		if _, err := interp.Eval(context.Background(), src); err != nil {
+			t.Errorf("%s: unexpected error: %v", src, err)
+		}
+	}
+}
+
+func ExampleInterpreter_RegisterFunc() {
+	interp := ferret.New()
+	interp.RegisterFunc("tax", func(amount, rate float64) float64 { return amount * rate })
+	v, _ := interp.Eval(context.Background(), "tax(200, 15%)")
+	fmt.Println(v)
+	// Output: 30.000000
+}
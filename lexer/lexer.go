@@ -3,6 +3,7 @@ package lexer
 import (
 	"strings"
 
+	"github.com/Richtermnd/ferret/diag"
 	"github.com/Richtermnd/ferret/token"
 )
 
@@ -14,6 +15,14 @@ type Lexer struct {
 	readpos int
 	line    int
 	col     int
+
+	// newline true if last token was preceded by a line break
+	newline bool
+
+	// start true until the first token is read
+	start bool
+
+	diagnostics []diag.Diagnostic
 }
 
 func New(source string) *Lexer {
@@ -21,6 +30,7 @@ func New(source string) *Lexer {
 		source: source,
 		line:   1,
 		col:    0,
+		start:  true,
 	}
 	return l
 }
@@ -28,7 +38,9 @@ func New(source string) *Lexer {
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 	l.readChar()
-	l.skipWhitespaces()
+	l.newline = false
+	trivia := l.skipTrivia()
+	pos := token.Pos{Line: l.line, Col: l.col}
 	switch l.ch {
 	case '\000':
 		tok = newToken(token.EOF, string(l.ch))
@@ -39,31 +51,55 @@ func (l *Lexer) NextToken() token.Token {
 	case '+':
 		tok = newToken(token.ADD, "+")
 	case '-':
-		tok = newToken(token.SUB, "-")
+		tok = l.switchSuffix(token.SUB, token.ARROW, '>')
 	case '*':
 		tok = newToken(token.MUL, "*")
 	case '/':
 		tok = newToken(token.DIV, "/")
 	case '%':
 		tok = newToken(token.REM, "%")
+	case '^':
+		tok = newToken(token.POW, "^")
 	case '(':
 		tok = newToken(token.LPAREN, "(")
 	case ')':
 		tok = newToken(token.RPAREN, ")")
+	case ',':
+		tok = newToken(token.COMMA, ",")
+	case ':':
+		tok = newToken(token.COLON, ":")
+	case '.':
+		tok = newToken(token.DOT, ".")
+	case '"':
+		tok = l.readString()
+	case '|':
+		tok = l.switchSuffix(token.ILLEGAL, token.PIPE, '>')
 	case '{':
-		tok = newToken(token.LBRACE, "(")
+		tok = newToken(token.LBRACE, "{")
 	case '}':
-		tok = newToken(token.RBRACE, ")")
+		tok = newToken(token.RBRACE, "}")
+	case '[':
+		tok = newToken(token.LBRACKET, "[")
+	case ']':
+		tok = newToken(token.RBRACKET, "]")
 	case '=':
 		tok = l.switchSuffix(token.ASSIGN, token.EQ, '=')
 	case '!':
+		if l.peekChar() == '=' && l.readpos+1 < len(l.source) && l.source[l.readpos+1] == '=' {
+			// there is no !==, it's factorial and equal: 3!==6
+			tok = token.Token{Type: token.NOT, Literal: "!"}
+			break
+		}
 		tok = l.switchSuffix(token.NOT, token.NEQ, '=')
 	case '>':
 		tok = l.switchSuffix(token.GT, token.GEQ, '=')
 	case '<':
 		tok = l.switchSuffix(token.LT, token.LEQ, '=')
+	case '#':
+		// comments are skipped, so it's a known pragma
+		tok = l.readPragma()
 	default:
-		if isLetter(l.ch) {
+		if isLetter(l.ch) || l.ch == '_' {
 			literal := l.readIdentifier()
 			t := token.LookupKeyword(literal)
 			tok = newToken(t, literal)
@@ -81,6 +117,9 @@ func (l *Lexer) NextToken() token.Token {
 		}
 	}
 
+	tok.Pos = pos
+	tok.Trivia = trivia
+	l.start = false
 	return tok
 }
 
@@ -103,6 +142,11 @@ func (l *Lexer) readChar() {
 }
 
 func (l *Lexer) unreadChar() {
+	if l.ch == '\n' {
+		l.line--
+	} else {
+		l.col--
+	}
 	l.readpos--
 	l.pos--
 	l.ch = l.source[l.pos]
@@ -116,10 +160,103 @@ func (l *Lexer) peekChar() byte {
 	}
 }
 
-func (l *Lexer) skipWhitespaces() {
+// NewlineBefore report if there was a line break before the last token
+func (l *Lexer) NewlineBefore() bool {
+	return l.newline
+}
+
+// Diagnostics return errors that can't be reported as a token,
+// like unterminated block comment
+func (l *Lexer) Diagnostics() []diag.Diagnostic {
+	return l.diagnostics
+}
+
+// skipWhitespaces skip whitespaces and report if there was a line break
+func (l *Lexer) skipWhitespaces() bool {
+	newline := false
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		if l.ch == '\n' {
+			l.newline = true
+			newline = true
+		}
+		l.readChar()
+	}
+	return newline
+}
+
+// skipTrivia skip whitespaces and comments, return comments as trivia of the next token
+func (l *Lexer) skipTrivia() *token.Trivia {
+	var trivia *token.Trivia
+	ownLine := l.skipWhitespaces() || l.start
+	for {
+		comment := token.Comment{Line: l.line, OwnLine: ownLine}
+		start := token.Pos{Line: l.line, Col: l.col}
+		switch {
+		case l.ch == '#' && !l.isPragma():
+			comment.Text = l.readLineComment()
+		case l.ch == '/' && l.peekChar() == '*':
+			text, ok := l.readBlockComment()
+			if !ok {
+				span := token.Span{Start: start, End: token.Pos{Line: l.line, Col: l.col}}
+				l.diagnostics = append(l.diagnostics,
+					diag.Errorf(span, "unterminated block comment starting at line %d", comment.Line).
+						WithHint("close it with */, block comments can be nested"))
+			}
+			// multiline comment separates tokens as a line break
+			if strings.Contains(text, "\n") {
+				l.newline = true
+			}
+			comment.Text = text
+		default:
+			return trivia
+		}
+		if trivia == nil {
+			trivia = &token.Trivia{}
+		}
+		trivia.Comments = append(trivia.Comments, comment)
+		l.readChar()
+		ownLine = l.skipWhitespaces()
+	}
+}
+
+// isPragma report if '#' starts a known pragma, otherwise it's a comment
+func (l *Lexer) isPragma() bool {
+	end := l.readpos
+	for end < len(l.source) && (isLetter(l.source[end]) || isDigit(l.source[end]) || l.source[end] == '_') {
+		end++
+	}
+	return token.IsPragma(l.source[l.readpos:end])
+}
+
+// readLineComment read comment until the end of line
+func (l *Lexer) readLineComment() string {
+	startPos := l.pos
+	for peek := l.peekChar(); peek != '\n' && peek != '\000'; peek = l.peekChar() {
 		l.readChar()
 	}
+	return l.source[startPos : l.pos+1]
+}
+
+// readBlockComment read /* */ comment, they can be nested,
+// ok is false if comment isn't closed before the end of source
+func (l *Lexer) readBlockComment() (text string, ok bool) {
+	startPos := l.pos
+	l.readChar()
+	depth := 1
+	for depth > 0 {
+		l.readChar()
+		switch {
+		case l.ch == '\000':
+			return l.source[startPos:], false
+		case l.ch == '/' && l.peekChar() == '*':
+			l.readChar()
+			depth++
+		case l.ch == '*' && l.peekChar() == '/':
+			l.readChar()
+			depth--
+		}
+	}
+	return l.source[startPos : l.pos+1], true
 }
 
 func (l *Lexer) readIdentifier() string {
@@ -131,8 +268,32 @@ func (l *Lexer) readIdentifier() string {
 	return l.source[startPos : l.pos+1]
 }
 
+// readString read "..." until closing quote on the same line, there are no escapes.
+// Literal is text between quotes, unterminated string is ILLEGAL
+func (l *Lexer) readString() token.Token {
+	startPos := l.pos + 1
+	for peek := l.peekChar(); peek != '"'; peek = l.peekChar() {
+		if peek == '\n' || peek == '\000' {
+			return newToken(token.ILLEGAL, l.source[startPos-1:l.pos+1])
+		}
+		l.readChar()
+	}
+	l.readChar()
+	return newToken(token.STRING, l.source[startPos:l.pos])
+}
+
+// readPragma read #name, literal is name without '#'
+func (l *Lexer) readPragma() token.Token {
+	l.readChar()
+	return newToken(token.PRAGMA, l.readIdentifier())
+}
+
 // readNumber read number, ignore '_' (python like syntax)
-// TODO: check for invalid number
+//
+// Letters after a number are not part of it (2x is 2 * x for parser),
+// except the exponent of scientific notation: 'e' or 'E' followed by digit
+// or by sign and digit (2e5, 2E-1, 2e+5).
+// 0x, 0o and 0b are reserved for numbers in different bases
 func (l *Lexer) readNumber() (string, token.TokenType) {
 	sb := strings.Builder{}
 	t := token.INT
@@ -147,21 +308,35 @@ func (l *Lexer) readNumber() (string, token.TokenType) {
 		l.readChar()
 	}
 
-	if isLetter(l.ch) {
+	if (l.ch == 'e' || l.ch == 'E') && l.isExponent() {
 		sb.WriteByte(l.ch)
-		if l.ch == 'e' {
+		l.readChar()
+		if l.ch == '-' || l.ch == '+' {
+			sb.WriteByte(l.ch)
 			l.readChar()
-			exp, _ := l.readNumber()
-			return sb.String() + exp, token.FLOAT
-		} else {
-			return sb.String(), token.ILLEGAL
 		}
+		exp, _ := l.readNumber()
+		return sb.String() + exp, token.FLOAT
+	}
+
+	if sb.String() == "0" && (l.ch == 'x' || l.ch == 'o' || l.ch == 'b') {
+		sb.WriteByte(l.ch)
+		return sb.String(), token.ILLEGAL
 	}
 
 	l.unreadChar()
 	return sb.String(), t
 }
 
+// isExponent report if 'e' is followed by digit or by sign and digit
+func (l *Lexer) isExponent() bool {
+	ch := l.peekChar()
+	if (ch == '-' || ch == '+') && l.readpos+1 < len(l.source) {
+		ch = l.source[l.readpos+1]
+	}
+	return isDigit(ch)
+}
+
 func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
 }
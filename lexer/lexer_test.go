@@ -8,14 +8,17 @@ import (
 )
 
 func TestOperandsRecognizing(t *testing.T) {
-	source := "+ - * / ( ) ; = == ! != > >= < <= $"
+	source := "+ - * / ^ ( ) [ ] ; = == ! != > >= < <= , |> : -> . !== $"
 	expected := []token.Token{
 		{Type: token.ADD, Literal: "+"},
 		{Type: token.SUB, Literal: "-"},
 		{Type: token.MUL, Literal: "*"},
 		{Type: token.DIV, Literal: "/"},
+		{Type: token.POW, Literal: "^"},
 		{Type: token.LPAREN, Literal: "("},
 		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.RBRACKET, Literal: "]"},
 		{Type: token.SEMICOLON, Literal: ";"},
 		{Type: token.ASSIGN, Literal: "="},
 		{Type: token.EQ, Literal: "=="},
@@ -25,11 +28,18 @@ func TestOperandsRecognizing(t *testing.T) {
 		{Type: token.GEQ, Literal: ">="},
 		{Type: token.LT, Literal: "<"},
 		{Type: token.LEQ, Literal: "<="},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.PIPE, Literal: "|>"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.ARROW, Literal: "->"},
+		{Type: token.DOT, Literal: "."},
+		{Type: token.NOT, Literal: "!"},
+		{Type: token.EQ, Literal: "=="},
 		{Type: token.ILLEGAL, Literal: "$"},
 	}
 	l := lexer.New(source)
 	for i, expectedToken := range expected {
-		tok := l.NextToken()
+		tok := nextToken(l)
 		t.Log(tok)
 		if expectedToken != tok {
 			t.Errorf("[%d] expected: %+v got: %+v\n", i, expectedToken, tok)
@@ -68,11 +78,27 @@ func TestNumbersRecognizing(t *testing.T) {
 			},
 		},
 		{
-			desc:   "invalid number",
+			desc:   "number followed by identifier",
 			source: "1a2",
+			expected: token.Token{
+				Type:    token.INT,
+				Literal: "1",
+			},
+		},
+		{
+			desc:   "number followed by e",
+			source: "2ex",
+			expected: token.Token{
+				Type:    token.INT,
+				Literal: "2",
+			},
+		},
+		{
+			desc:   "reserved base prefix",
+			source: "0x1f",
 			expected: token.Token{
 				Type:    token.ILLEGAL,
-				Literal: "1a",
+				Literal: "0x",
 			},
 		},
 		{
@@ -83,11 +109,43 @@ func TestNumbersRecognizing(t *testing.T) {
 				Literal: "1.2e3",
 			},
 		},
+		{
+			desc:   "negative exponent",
+			source: "2e-1",
+			expected: token.Token{
+				Type:    token.FLOAT,
+				Literal: "2e-1",
+			},
+		},
+		{
+			desc:   "positive exponent",
+			source: "2e+5",
+			expected: token.Token{
+				Type:    token.FLOAT,
+				Literal: "2e+5",
+			},
+		},
+		{
+			desc:   "upper case exponent",
+			source: "2E5",
+			expected: token.Token{
+				Type:    token.FLOAT,
+				Literal: "2E5",
+			},
+		},
+		{
+			desc:   "e followed by sign and identifier",
+			source: "2e-x",
+			expected: token.Token{
+				Type:    token.INT,
+				Literal: "2",
+			},
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
 			l := lexer.New(tC.source)
-			tok := l.NextToken()
+			tok := nextToken(l)
 			if tok != tC.expected {
 				t.Errorf("expected: %+v got: %+v\n", tC.expected, tok)
 			}
@@ -116,7 +174,7 @@ func TestExpression(t *testing.T) {
 	}
 	l := lexer.New(source)
 	for i, expectedToken := range expected {
-		tok := l.NextToken()
+		tok := nextToken(l)
 		t.Logf("%s\n", tok.Literal)
 		if expectedToken != tok {
 			t.Errorf("[%d] expected: %s got: %s\n", i, expectedToken.Literal, tok.Literal)
@@ -125,7 +183,7 @@ func TestExpression(t *testing.T) {
 }
 
 func TestKeywords(t *testing.T) {
-	source := "let true false and or if else"
+	source := "let true false and or if else fn import as"
 	expected := []token.Token{
 		{Type: token.LET, Literal: "let"},
 		{Type: token.TRUE, Literal: "true"},
@@ -134,10 +192,13 @@ func TestKeywords(t *testing.T) {
 		{Type: token.OR, Literal: "or"},
 		{Type: token.IF, Literal: "if"},
 		{Type: token.ELSE, Literal: "else"},
+		{Type: token.FN, Literal: "fn"},
+		{Type: token.IMPORT, Literal: "import"},
+		{Type: token.AS, Literal: "as"},
 	}
 	l := lexer.New(source)
 	for i, expectedToken := range expected {
-		tok := l.NextToken()
+		tok := nextToken(l)
 		t.Logf("%s\n", tok.Literal)
 		if expectedToken.Type != tok.Type {
 			t.Errorf("[%d] mismatch type expected: %d got: %d\n", i, expectedToken.Type, tok.Type)
@@ -147,3 +208,145 @@ func TestKeywords(t *testing.T) {
 		}
 	}
 }
+
+func TestStrings(t *testing.T) {
+	source := "\"stats.fe\" \"\" \"lib/a b.fe\n\""
+	expected := []token.Token{
+		{Type: token.STRING, Literal: "stats.fe"},
+		{Type: token.STRING, Literal: ""},
+		// no escapes and no line breaks inside of string
+		{Type: token.ILLEGAL, Literal: "\"lib/a b.fe"},
+		{Type: token.ILLEGAL, Literal: "\""},
+	}
+	l := lexer.New(source)
+	for i, expectedToken := range expected {
+		tok := nextToken(l)
+		if expectedToken != tok {
+			t.Errorf("[%d] expected: %+v got: %+v\n", i, expectedToken, tok)
+		}
+	}
+}
+
+func TestPragma(t *testing.T) {
+	// only known pragmas, everything else after '#' is a comment
+	source := "#strict #stricter #1"
+	expected := []token.Token{
+		{Type: token.PRAGMA, Literal: "strict"},
+		{Type: token.EOF, Literal: "\000"},
+	}
+	l := lexer.New(source)
+	for i, expectedToken := range expected {
+		tok := nextToken(l)
+		tok.Trivia = nil
+		if expectedToken != tok {
+			t.Errorf("[%d] expected: %+v got: %+v\n", i, expectedToken, tok)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	source := `# first
+1 + /* inline /* nested */ */ 2 # trailing
+/* multi
+line */ 3`
+	expected := []struct {
+		tok      token.Token
+		comments []token.Comment
+	}{
+		{
+			tok: token.Token{Type: token.INT, Literal: "1"},
+			comments: []token.Comment{
+				{Text: "# first", Line: 1, OwnLine: true},
+			},
+		},
+		{
+			tok: token.Token{Type: token.ADD, Literal: "+"},
+		},
+		{
+			tok: token.Token{Type: token.INT, Literal: "2"},
+			comments: []token.Comment{
+				{Text: "/* inline /* nested */ */", Line: 2, OwnLine: false},
+			},
+		},
+		{
+			tok: token.Token{Type: token.INT, Literal: "3"},
+			comments: []token.Comment{
+				{Text: "# trailing", Line: 2, OwnLine: false},
+				{Text: "/* multi\nline */", Line: 3, OwnLine: true},
+			},
+		},
+		{
+			tok: token.Token{Type: token.EOF, Literal: "\000"},
+		},
+	}
+	l := lexer.New(source)
+	for i, tt := range expected {
+		tok := nextToken(l)
+		var comments []token.Comment
+		if tok.Trivia != nil {
+			comments = tok.Trivia.Comments
+		}
+		tok.Trivia = nil
+		if tt.tok != tok {
+			t.Errorf("[%d] expected: %+v got: %+v\n", i, tt.tok, tok)
+		}
+		if len(tt.comments) != len(comments) {
+			t.Errorf("[%d] expected comments: %+v got: %+v\n", i, tt.comments, comments)
+			continue
+		}
+		for j := range comments {
+			if tt.comments[j] != comments[j] {
+				t.Errorf("[%d] expected comment: %+v got: %+v\n", i, tt.comments[j], comments[j])
+			}
+		}
+	}
+	if len(l.Diagnostics()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Diagnostics())
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := lexer.New("1\n/* a /* b */\n2")
+	for tok := l.NextToken(); !tok.Is(token.EOF); tok = l.NextToken() {
+	}
+	errs := l.Diagnostics()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error got: %v\n", errs)
+	}
+	expected := "2:1: unterminated block comment starting at line 2"
+	if errs[0].Error() != expected {
+		t.Errorf("expected: %q got: %q\n", expected, errs[0].Error())
+	}
+}
+
+// nextToken return next token without position, tests compare only type and literal
+func nextToken(l *lexer.Lexer) token.Token {
+	tok := l.NextToken()
+	tok.Pos = token.Pos{}
+	return tok
+}
+
+func TestPositions(t *testing.T) {
+	source := "let a = 1\n  a + 22 # comment\n/* \n */ b"
+	expected := []token.Pos{
+		{Line: 1, Col: 1},
+		{Line: 1, Col: 5},
+		{Line: 1, Col: 7},
+		{Line: 1, Col: 9},
+		{Line: 2, Col: 3},
+		{Line: 2, Col: 5},
+		{Line: 2, Col: 7},
+		{Line: 4, Col: 5},
+	}
+	l := lexer.New(source)
+	for i, pos := range expected {
+		tok := l.NextToken()
+		if tok.Pos != pos {
+			t.Errorf("[%d] %s expected: %s got: %s\n", i, tok, pos, tok.Pos)
+		}
+	}
+	tok := l.NextToken()
+	if !tok.Is(token.EOF) {
+		t.Errorf("expected EOF got: %s\n", tok)
+	}
+}
@@ -0,0 +1,274 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// document is an open text document and result of its analysis
+type document struct {
+	uri     string
+	version int
+	text    string
+	lines   []string
+
+	program     *ast.Program
+	diagnostics []diag.Diagnostic
+
+	// bindings is let, fn and import statements in order of appearance
+	bindings []*binding
+
+	// refs is every identifier in document: definitions and usages
+	refs []reference
+}
+
+// binding is a name defined by let, fn or import statement
+// and its value if it can be evaluated
+type binding struct {
+	stmt  ast.Statement
+	ident *ast.Identifier
+	value object.Object // nil if value is an error or a module
+}
+
+func (b *binding) name() string {
+	return b.ident.Value
+}
+
+// start is position of statement keyword
+func (b *binding) start() token.Pos {
+	return ast.Span(b.stmt).Start
+}
+
+// kinds return symbol and completion kinds of binding
+func (b *binding) kinds() (int, int) {
+	switch b.stmt.(type) {
+	case *ast.FunctionStatement:
+		return SymbolFunction, CompletionFunction
+	case *ast.ImportStatement:
+		return SymbolModule, CompletionModule
+	}
+	return SymbolVariable, CompletionVariable
+}
+
+// describe return declaration of binding for hover
+func (b *binding) describe() string {
+	switch stmt := b.stmt.(type) {
+	case *ast.FunctionStatement:
+		return "fn " + stmt.Signature()
+	case *ast.ImportStatement:
+		return stmt.String()
+	}
+	if b.value != nil {
+		return fmt.Sprintf("let %s: %s = %s", b.name(), b.value.Type(), b.value.Inspect())
+	}
+	return "let " + b.name()
+}
+
+// detail return short description of binding for symbols
+func (b *binding) detail() string {
+	switch stmt := b.stmt.(type) {
+	case *ast.FunctionStatement:
+		return stmt.Signature()
+	case *ast.ImportStatement:
+		return strconv.Quote(stmt.Path.Literal)
+	}
+	if b.value != nil {
+		return fmt.Sprintf("%s = %s", b.value.Type(), b.value.Inspect())
+	}
+	return ""
+}
+
+// define add binding of stmt to scope
+func (doc *document) define(sc *scope, stmt ast.Statement, ident *ast.Identifier, value object.Object) {
+	b := &binding{stmt: stmt, ident: ident, value: value}
+	sc.names[b.name()] = b
+	doc.bindings = append(doc.bindings, b)
+	doc.refs = append(doc.refs, reference{ident: ident, binding: b})
+}
+
+// reference is an identifier and binding it refers to, nil for builtins and unknown names
+type reference struct {
+	ident   *ast.Identifier
+	binding *binding
+}
+
+// Analysis runs on every change, values that take longer or are bigger
+// than these limits are unknown
+const analysisTimeout = 100 * time.Millisecond
+
+var analysisLimits = evaluator.Limits{MaxSteps: 100_000, MaxDepth: 1000, MaxAlloc: 10_000}
+
+func newDocument(uri string, version int, text string) *document {
+	doc := &document{uri: uri, version: version, text: text, lines: strings.Split(text, "\n")}
+	p := parser.New(lexer.New(text))
+	doc.program = p.Parse()
+	doc.diagnostics = p.Diagnostics()
+	ctx, cancel := context.WithTimeout(context.Background(), analysisTimeout)
+	defer cancel()
+	doc.analyze(ctx, doc.program.Statements, newScope(nil), object.NewEnv())
+	return doc
+}
+
+// scope maps names to bindings, blocks have their own scopes like in evaluator
+type scope struct {
+	outer *scope
+	names map[string]*binding
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, names: make(map[string]*binding)}
+}
+
+func (s *scope) lookup(name string) *binding {
+	for ; s != nil; s = s.outer {
+		if b, ok := s.names[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// analyze resolve identifiers and evaluate let values statement by statement
+// within analysisLimits until ctx is done
+func (doc *document) analyze(ctx context.Context, stmts []ast.Statement, sc *scope, env *object.Environment) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.BlockStatement:
+			doc.analyze(ctx, stmt.Statements, newScope(sc), env.SubEnv())
+			continue
+		case *ast.LetStatement:
+			doc.resolve(stmt.Value, sc)
+			value := evaluator.EvalContext(ctx, env, stmt.Value, analysisLimits)
+			if value != nil && stmt.Type != nil {
+				value = evaluator.Annotate(stmt.Name.Value, stmt.Type.Name, value)
+			}
+			if value != nil && object.IsError(value) {
+				value = nil
+			}
+			if value != nil {
+				env.Set(stmt.Name.Value, value)
+			}
+			doc.define(sc, stmt, stmt.Name, value)
+			continue
+		case *ast.FunctionStatement:
+			evaluator.Eval(env, stmt)
+			fn, _ := env.Get(stmt.Name.Value)
+			// defined before body, so recursive calls refer to it
+			doc.define(sc, stmt, stmt.Name, fn)
+			// parameters shadow outer names, their values are unknown,
+			// so body is evaluated in its own environment without them
+			body := newScope(sc)
+			for _, param := range stmt.Parameters {
+				body.names[param.Name.Value] = nil
+				doc.refs = append(doc.refs, reference{ident: param.Name})
+			}
+			doc.analyze(ctx, stmt.Body.Statements, body, object.NewEnv())
+			continue
+		case *ast.ImportStatement:
+			// modules are not loaded, alias is defined with unknown value
+			doc.define(sc, stmt, stmt.Alias, nil)
+			continue
+		case *ast.PragmaStatement:
+			evaluator.Eval(env, stmt)
+		}
+		doc.resolve(stmt, sc)
+	}
+}
+
+func (doc *document) resolve(node ast.Node, sc *scope) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok {
+			doc.refs = append(doc.refs, reference{ident: ident, binding: sc.lookup(ident.Value)})
+		}
+		return true
+	})
+}
+
+// referenceAt return identifier at position
+func (doc *document) referenceAt(pos token.Pos) (reference, bool) {
+	for _, ref := range doc.refs {
+		if ref.ident.Token.Span().Contains(pos) {
+			return ref, true
+		}
+	}
+	return reference{}, false
+}
+
+// toPosition convert ferret position to LSP one
+func (doc *document) toPosition(pos token.Pos) Position {
+	line := pos.Line - 1
+	if line < 0 {
+		return Position{}
+	}
+	if line >= len(doc.lines) {
+		last := len(doc.lines) - 1
+		return Position{Line: last, Character: utf16Len(doc.lines[last])}
+	}
+	text := doc.lines[line]
+	col := min(max(pos.Col-1, 0), len(text))
+	return Position{Line: line, Character: utf16Len(text[:col])}
+}
+
+// fromPosition convert LSP position to ferret one
+func (doc *document) fromPosition(pos Position) token.Pos {
+	if pos.Line < 0 || pos.Line >= len(doc.lines) {
+		return token.Pos{}
+	}
+	text := doc.lines[pos.Line]
+	units, col := 0, 0
+	for col < len(text) && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(text[col:])
+		units += runeLen(r)
+		col += size
+	}
+	return token.Pos{Line: pos.Line + 1, Col: col + 1}
+}
+
+func (doc *document) toRange(span token.Span) Range {
+	return Range{Start: doc.toPosition(span.Start), End: doc.toPosition(span.End)}
+}
+
+// fullRange is a range of the whole document
+func (doc *document) fullRange() Range {
+	last := len(doc.lines) - 1
+	return Range{End: Position{Line: last, Character: utf16Len(doc.lines[last])}}
+}
+
+// utf16Len return length of s in UTF-16 code units, LSP positions use them
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen(r)
+	}
+	return n
+}
+
+func runeLen(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1 // invalid utf-8 is replaced with U+FFFD
+}
+
+// statementSpan return span of statement from its keyword to the end of line where it ends,
+// ast doesn't keep closing parenthesis
+func (doc *document) statementSpan(stmt ast.Statement) token.Span {
+	span := ast.Span(stmt)
+	if line := span.End.Line - 1; line < len(doc.lines) {
+		span.End.Col = max(span.End.Col, len(strings.TrimRight(doc.lines[line], " \t\r"))+1)
+	}
+	return span
+}
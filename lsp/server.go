@@ -0,0 +1,345 @@
+// Package lsp implements Language Server Protocol server for ferret over stdio:
+// diagnostics, hover, go to definition, document symbols, completion and formatting
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/format"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Server serve one client, documents are synced fully on every change
+type Server struct {
+	conn *Conn
+	docs map[string]*document
+
+	// shutdown true after shutdown request, only exit is expected then
+	shutdown bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		conn: NewConn(in, out),
+		docs: make(map[string]*document),
+	}
+}
+
+type handler func(s *Server, params json.RawMessage) (any, error)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":                  (*Server).initialize,
+		"initialized":                 nop,
+		"shutdown":                    (*Server).shutdownRequest,
+		"textDocument/didOpen":        (*Server).didOpen,
+		"textDocument/didChange":      (*Server).didChange,
+		"textDocument/didClose":       (*Server).didClose,
+		"textDocument/didSave":        nop,
+		"textDocument/hover":          (*Server).hover,
+		"textDocument/definition":     (*Server).definition,
+		"textDocument/documentSymbol": (*Server).documentSymbol,
+		"textDocument/completion":     (*Server).completion,
+		"textDocument/formatting":     (*Server).formatting,
+	}
+}
+
+func nop(*Server, json.RawMessage) (any, error) { return nil, nil }
+
+// Serve handle messages until exit notification or end of input
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.conn.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var respErr *ResponseError
+		if errors.As(err, &respErr) {
+			// malformed JSON, there is no id to reply to
+			s.conn.Reply(nil, nil, respErr)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *Message) error {
+	if msg.Method == "" {
+		// response to server request, server doesn't send them
+		return nil
+	}
+	h, ok := handlers[msg.Method]
+	if msg.IsNotification() {
+		if ok {
+			// notifications can't report errors
+			h(s, msg.Params)
+		}
+		return nil
+	}
+	if !ok {
+		return s.conn.Reply(msg.ID, nil, &ResponseError{Code: MethodNotFound, Message: "unknown method " + msg.Method})
+	}
+	if s.shutdown {
+		return s.conn.Reply(msg.ID, nil, &ResponseError{Code: InvalidRequest, Message: "server is shut down"})
+	}
+	result, err := h(s, msg.Params)
+	if err != nil {
+		var respErr *ResponseError
+		if !errors.As(err, &respErr) {
+			respErr = &ResponseError{Code: InternalError, Message: err.Error()}
+		}
+		return s.conn.Reply(msg.ID, nil, respErr)
+	}
+	return s.conn.Reply(msg.ID, result, nil)
+}
+
+// unmarshal params, error is InvalidParams response error
+func unmarshal(params json.RawMessage, v any) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &ResponseError{Code: InvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &ResponseError{Code: InvalidParams, Message: "document is not open: " + uri}
+	}
+	return doc, nil
+}
+
+func (s *Server) initialize(json.RawMessage) (any, error) {
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           SyncFull,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentSymbolProvider:     true,
+			CompletionProvider:         &CompletionOptions{},
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "ferret"},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (any, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(raw json.RawMessage) (any, error) {
+	var params DidOpenTextDocumentParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	item := params.TextDocument
+	return nil, s.update(newDocument(item.URI, item.Version, item.Text))
+}
+
+func (s *Server) didChange(raw json.RawMessage) (any, error) {
+	var params DidChangeTextDocumentParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.ContentChanges) == 0 {
+		return nil, nil
+	}
+	// full sync: the last change is the whole text
+	text := params.ContentChanges[len(params.ContentChanges)-1].Text
+	return nil, s.update(newDocument(params.TextDocument.URI, params.TextDocument.Version, text))
+}
+
+func (s *Server) didClose(raw json.RawMessage) (any, error) {
+	var params DidCloseTextDocumentParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	delete(s.docs, params.TextDocument.URI)
+	// clear diagnostics of closed document
+	return nil, s.conn.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// update replace document and publish its diagnostics
+func (s *Server) update(doc *document) error {
+	s.docs[doc.uri] = doc
+	diagnostics := make([]Diagnostic, 0, len(doc.diagnostics))
+	for _, d := range doc.diagnostics {
+		message := d.Message
+		if d.Hint != "" {
+			message += "\nhint: " + d.Hint
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    doc.toRange(d.Span),
+			Severity: severity(d.Severity),
+			Source:   "ferret",
+			Message:  message,
+		})
+	}
+	return s.conn.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: diagnostics,
+	})
+}
+
+func severity(s diag.Severity) int {
+	switch s {
+	case diag.Warning:
+		return SeverityWarning
+	case diag.Info:
+		return SeverityInformation
+	}
+	return SeverityError
+}
+
+func (s *Server) hover(raw json.RawMessage) (any, error) {
+	var params TextDocumentPositionParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := doc.referenceAt(doc.fromPosition(params.Position))
+	if !ok {
+		return nil, nil
+	}
+	var text string
+	switch {
+	case ref.binding != nil:
+		text = ref.binding.describe()
+	default:
+		builtin, ok := object.LookupBuiltin(ref.ident.Value)
+		if !ok {
+			return nil, nil
+		}
+		text = fmt.Sprintf("builtin %s", builtin.Inspect())
+	}
+	r := doc.toRange(ref.ident.Token.Span())
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```ferret\n" + text + "\n```"},
+		Range:    &r,
+	}, nil
+}
+
+func (s *Server) definition(raw json.RawMessage) (any, error) {
+	var params TextDocumentPositionParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := doc.referenceAt(doc.fromPosition(params.Position))
+	if !ok || ref.binding == nil {
+		return nil, nil
+	}
+	return Location{URI: doc.uri, Range: doc.toRange(ref.binding.ident.Token.Span())}, nil
+}
+
+func (s *Server) documentSymbol(raw json.RawMessage) (any, error) {
+	var params DocumentSymbolParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]DocumentSymbol, 0, len(doc.bindings))
+	for _, b := range doc.bindings {
+		kind, _ := b.kinds()
+		symbols = append(symbols, DocumentSymbol{
+			Name:           b.name(),
+			Detail:         b.detail(),
+			Kind:           kind,
+			Range:          doc.toRange(doc.statementSpan(b.stmt)),
+			SelectionRange: doc.toRange(b.ident.Token.Span()),
+		})
+	}
+	return symbols, nil
+}
+
+func (s *Server) completion(raw json.RawMessage) (any, error) {
+	var params CompletionParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var items []CompletionItem
+	add := func(item CompletionItem) {
+		if !seen[item.Label] {
+			seen[item.Label] = true
+			items = append(items, item)
+		}
+	}
+	// names defined before cursor, the closest definition wins
+	pos := doc.fromPosition(params.Position)
+	for _, b := range slices.Backward(doc.bindings) {
+		if !b.start().Less(pos) {
+			continue
+		}
+		_, kind := b.kinds()
+		item := CompletionItem{Label: b.name(), Kind: kind}
+		if b.value != nil {
+			item.Detail = string(b.value.Type())
+		}
+		add(item)
+	}
+	for _, name := range object.BuiltinNames() {
+		add(CompletionItem{Label: name, Kind: CompletionFunction, Detail: "builtin"})
+	}
+	for _, keyword := range slices.Sorted(slices.Values(token.Keywords())) {
+		add(CompletionItem{Label: keyword, Kind: CompletionKeyword})
+	}
+	return items, nil
+}
+
+func (s *Server) formatting(raw json.RawMessage) (any, error) {
+	var params DocumentFormattingParams
+	if err := unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source([]byte(doc.text))
+	if err != nil {
+		// document with errors is left as is, diagnostics already show them
+		return []TextEdit{}, nil
+	}
+	if string(formatted) == doc.text {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{Range: doc.fullRange(), NewText: string(formatted)}}, nil
+}
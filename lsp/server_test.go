@@ -0,0 +1,369 @@
+package lsp_test
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Richtermnd/ferret/lsp"
+)
+
+const uri = "file:///test.fe"
+
+// client is in-process JSON-RPC client connected to server with pipes
+type client struct {
+	t      *testing.T
+	conn   *lsp.Conn
+	id     int
+	notifs []*lsp.Message
+	done   chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &client{t: t, conn: lsp.NewConn(clientIn, clientOut), done: make(chan error, 1)}
+	go func() {
+		err := lsp.NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() {
+		clientOut.Close()
+		clientIn.Close()
+	})
+	c.call("initialize", lsp.InitializeParams{}, nil)
+	c.notify("initialized", struct{}{})
+	return c
+}
+
+// call send request and wait for response, notifications received meanwhile are kept
+func (c *client) call(method string, params, result any) {
+	c.t.Helper()
+	c.id++
+	if err := c.conn.Call(c.id, method, params); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg, err := c.conn.Read()
+		if err != nil {
+			c.t.Fatalf("%s: %v", method, err)
+		}
+		if msg.Method != "" {
+			c.notifs = append(c.notifs, msg)
+			continue
+		}
+		if id, _ := strconv.Atoi(string(*msg.ID)); id != c.id {
+			c.t.Fatalf("%s: expected response %d got %d", method, c.id, id)
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: %v", method, msg.Error)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("%s: %v", method, err)
+			}
+		}
+		return
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	if err := c.conn.Notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// diagnostics wait for the next published diagnostics
+func (c *client) diagnostics() lsp.PublishDiagnosticsParams {
+	c.t.Helper()
+	var msg *lsp.Message
+	if len(c.notifs) > 0 {
+		msg, c.notifs = c.notifs[0], c.notifs[1:]
+	} else {
+		var err error
+		if msg, err = c.conn.Read(); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected diagnostics got %s", msg.Method)
+	}
+	var params lsp.PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+func (c *client) open(text string) {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "ferret", Version: 1, Text: text},
+	})
+}
+
+func at(line, character int) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     lsp.Position{Line: line, Character: character},
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+	c.open("let a = 1 +\nlet b = )")
+	params := c.diagnostics()
+	if params.URI != uri {
+		t.Errorf("wrong uri: %s", params.URI)
+	}
+	expected := []lsp.Range{
+		{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 3}},
+		{Start: lsp.Position{Line: 1, Character: 8}, End: lsp.Position{Line: 1, Character: 9}},
+	}
+	if len(params.Diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics got: %+v", len(expected), params.Diagnostics)
+	}
+	for i, d := range params.Diagnostics {
+		if d.Range != expected[i] || d.Severity != lsp.SeverityError {
+			t.Errorf("[%d] expected range %+v got: %+v", i, expected[i], d)
+		}
+	}
+
+	// fixed document clears diagnostics
+	c.notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: "let a = 1"}},
+	})
+	if params := c.diagnostics(); len(params.Diagnostics) != 0 || params.Version != 2 {
+		t.Errorf("expected no diagnostics for version 2 got: %+v", params)
+	}
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	c.open("let rate = 5%\nlet total = 200 * rate\ntotal |> sqrt")
+	c.diagnostics()
+
+	testCases := []struct {
+		line, character int
+		expected        string
+	}{
+		{line: 0, character: 5, expected: "let rate: FLOAT = 0.05"},
+		{line: 1, character: 19, expected: "let rate: FLOAT = 0.05"},
+		{line: 2, character: 0, expected: "let total: FLOAT = 10"},
+		{line: 2, character: 10, expected: "builtin sqrt"},
+		{line: 1, character: 14, expected: ""},
+	}
+	for _, tt := range testCases {
+		var hover *lsp.Hover
+		c.call("textDocument/hover", at(tt.line, tt.character), &hover)
+		if tt.expected == "" {
+			if hover != nil {
+				t.Errorf("%d:%d expected no hover got: %+v", tt.line, tt.character, hover)
+			}
+			continue
+		}
+		if hover == nil || !strings.Contains(hover.Contents.Value, tt.expected) {
+			t.Errorf("%d:%d expected %q got: %+v", tt.line, tt.character, tt.expected, hover)
+		}
+	}
+}
+
+func TestAnalysisLimits(t *testing.T) {
+	c := newClient(t)
+	start := time.Now()
+	c.open("let a = 1000000!\nfn f(x) { f(x) }\nlet b = f(1)\nlet c = 2")
+	c.diagnostics()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("analysis took %s", elapsed)
+	}
+
+	testCases := []struct {
+		line, character int
+		expected        string
+	}{
+		{line: 0, character: 4, expected: "let a\n"},
+		{line: 2, character: 4, expected: "let b\n"},
+		{line: 3, character: 4, expected: "let c: INTEGER = 2"},
+	}
+	for _, tt := range testCases {
+		var hover *lsp.Hover
+		c.call("textDocument/hover", at(tt.line, tt.character), &hover)
+		if hover == nil || !strings.Contains(hover.Contents.Value, tt.expected) {
+			t.Errorf("%d:%d expected %q got: %+v", tt.line, tt.character, tt.expected, hover)
+		}
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t)
+	c.open("let a = 1\n{\n  let a = 2\n  a\n}\na + b")
+	c.diagnostics()
+
+	testCases := []struct {
+		line, character int
+		expected        *lsp.Range
+	}{
+		{line: 3, character: 2, expected: &lsp.Range{Start: lsp.Position{Line: 2, Character: 6}, End: lsp.Position{Line: 2, Character: 7}}},
+		{line: 5, character: 0, expected: &lsp.Range{Start: lsp.Position{Line: 0, Character: 4}, End: lsp.Position{Line: 0, Character: 5}}},
+		{line: 5, character: 4, expected: nil},
+	}
+	for _, tt := range testCases {
+		var location *lsp.Location
+		c.call("textDocument/definition", at(tt.line, tt.character), &location)
+		switch {
+		case tt.expected == nil && location != nil:
+			t.Errorf("%d:%d expected no definition got: %+v", tt.line, tt.character, location)
+		case tt.expected != nil && (location == nil || location.Range != *tt.expected || location.URI != uri):
+			t.Errorf("%d:%d expected %+v got: %+v", tt.line, tt.character, tt.expected, location)
+		}
+	}
+}
+
+func TestFunctionScope(t *testing.T) {
+	c := newClient(t)
+	c.open("let pi = 3\nlet r: float = 2\nfn area(r) { pi * r * r }\narea(r)")
+	c.diagnostics()
+
+	testCases := []struct {
+		line, character int
+		expected        *lsp.Range
+	}{
+		// parameter shadows r
+		{line: 2, character: 18, expected: nil},
+		{line: 2, character: 13, expected: &lsp.Range{Start: lsp.Position{Line: 0, Character: 4}, End: lsp.Position{Line: 0, Character: 6}}},
+		{line: 3, character: 5, expected: &lsp.Range{Start: lsp.Position{Line: 1, Character: 4}, End: lsp.Position{Line: 1, Character: 5}}},
+		{line: 3, character: 1, expected: &lsp.Range{Start: lsp.Position{Line: 2, Character: 3}, End: lsp.Position{Line: 2, Character: 7}}},
+	}
+	for _, tt := range testCases {
+		var location *lsp.Location
+		c.call("textDocument/definition", at(tt.line, tt.character), &location)
+		switch {
+		case tt.expected == nil && location != nil:
+			t.Errorf("%d:%d expected no definition got: %+v", tt.line, tt.character, location)
+		case tt.expected != nil && (location == nil || location.Range != *tt.expected):
+			t.Errorf("%d:%d expected %+v got: %+v", tt.line, tt.character, tt.expected, location)
+		}
+	}
+
+	var hover *lsp.Hover
+	c.call("textDocument/hover", at(3, 5), &hover)
+	if hover == nil || !strings.Contains(hover.Contents.Value, "let r: FLOAT = 2.000000") {
+		t.Errorf("expected annotated value got: %+v", hover)
+	}
+	c.call("textDocument/hover", at(3, 1), &hover)
+	if hover == nil || !strings.Contains(hover.Contents.Value, "fn area(r)") {
+		t.Errorf("expected signature got: %+v", hover)
+	}
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c := newClient(t)
+	c.open("let a = 1\nlet b = a * (2 + 3)\n{ let c = true }\nfn sq(x) {\n  x * x\n}\nimport \"lib.fe\" as m")
+	c.diagnostics()
+
+	var symbols []lsp.DocumentSymbol
+	c.call("textDocument/documentSymbol", lsp.DocumentSymbolParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}}, &symbols)
+	expected := []struct {
+		name, detail string
+		kind         int
+	}{
+		{name: "a", detail: "INTEGER = 1", kind: lsp.SymbolVariable},
+		{name: "b", detail: "INTEGER = 5", kind: lsp.SymbolVariable},
+		{name: "c", detail: "BOOL = true", kind: lsp.SymbolVariable},
+		{name: "sq", detail: "sq(x)", kind: lsp.SymbolFunction},
+		{name: "m", detail: "\"lib.fe\"", kind: lsp.SymbolModule},
+	}
+	if len(symbols) != len(expected) {
+		t.Fatalf("expected %d symbols got: %+v", len(expected), symbols)
+	}
+	for i, symbol := range symbols {
+		if symbol.Name != expected[i].name || symbol.Detail != expected[i].detail || symbol.Kind != expected[i].kind {
+			t.Errorf("[%d] expected %+v got: %+v", i, expected[i], symbol)
+		}
+	}
+	if r := symbols[1].Range; r.Start != (lsp.Position{Line: 1, Character: 0}) || r.End != (lsp.Position{Line: 1, Character: 19}) {
+		t.Errorf("wrong range of b: %+v", r)
+	}
+	if r := symbols[3].Range; r.Start != (lsp.Position{Line: 3, Character: 0}) || r.End != (lsp.Position{Line: 5, Character: 1}) {
+		t.Errorf("wrong range of sq: %+v", r)
+	}
+	if r := symbols[4].SelectionRange; r.Start != (lsp.Position{Line: 6, Character: 19}) || r.End != (lsp.Position{Line: 6, Character: 20}) {
+		t.Errorf("wrong selection range of m: %+v", r)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t)
+	c.open("let alpha = 1\n\nlet beta = 2")
+	c.diagnostics()
+
+	var items []lsp.CompletionItem
+	c.call("textDocument/completion", lsp.CompletionParams{TextDocumentPositionParams: at(1, 0)}, &items)
+	kinds := make(map[string]int)
+	for _, item := range items {
+		kinds[item.Label] = item.Kind
+	}
+	expected := map[string]int{
+		"alpha": lsp.CompletionVariable,
+		"sqrt":  lsp.CompletionFunction,
+		"let":   lsp.CompletionKeyword,
+	}
+	for label, kind := range expected {
+		if kinds[label] != kind {
+			t.Errorf("expected %s with kind %d got: %d", label, kind, kinds[label])
+		}
+	}
+	if _, ok := kinds["beta"]; ok {
+		t.Errorf("beta is defined after cursor")
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	c.open("let a=(1+2)*3\n a|>sqrt # root")
+	c.diagnostics()
+
+	var edits []lsp.TextEdit
+	params := lsp.DocumentFormattingParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}}
+	c.call("textDocument/formatting", params, &edits)
+	if len(edits) != 1 {
+		t.Fatalf("expected 1 edit got: %+v", edits)
+	}
+	expected := "let a = (1 + 2) * 3\na |> sqrt # root\n"
+	if edits[0].NewText != expected {
+		t.Errorf("expected %q got %q", expected, edits[0].NewText)
+	}
+	if edits[0].Range.End != (lsp.Position{Line: 1, Character: 15}) {
+		t.Errorf("edit doesn't cover whole document: %+v", edits[0].Range)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	c := newClient(t)
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	c := newClient(t)
+	c.id++
+	if err := c.conn.Call(c.id, "unknown/method", nil); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := c.conn.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Error == nil || msg.Error.Code != lsp.MethodNotFound {
+		t.Errorf("expected method not found error got: %+v", msg)
+	}
+}
@@ -0,0 +1,142 @@
+package object
+
+import (
+	"math/big"
+)
+
+const BIGINT_OBJ ObjectType = "BIGINT"
+
+// BigInt is an integer that doesn't fit in int64 (like 25!)
+type BigInt struct {
+	Value *big.Int
+}
+
+func (o BigInt) Type() ObjectType { return BIGINT_OBJ }
+func (o BigInt) Inspect() string  { return o.Value.String() }
+
+// NewBigInt return Integer if value fits in int64 and BigInt otherwise
+func NewBigInt(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	return &BigInt{Value: value}
+}
+
+func (o *BigInt) AsFloat() *Float {
+	f, _ := new(big.Float).SetInt(o.Value).Float64()
+	return &Float{Value: f}
+}
+
+func (o *BigInt) Neg() Object {
+	return NewBigInt(new(big.Int).Neg(o.Value))
+}
+
+func (o *BigInt) Abs() Object {
+	return NewBigInt(new(big.Int).Abs(o.Value))
+}
+
+// asBig represent integer objects as big.Int
+func asBig(obj Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *BigInt:
+		return obj.Value, true
+	case *Integer:
+		return big.NewInt(obj.Value), true
+	}
+	return nil, false
+}
+
+func (o *BigInt) Add(right Object) Object {
+	if r, ok := asBig(right); ok {
+		return NewBigInt(new(big.Int).Add(o.Value, r))
+	}
+	if r, ok := right.(*Float); ok {
+		return o.AsFloat().Add(r)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "+", right.Type())
+}
+
+func (o *BigInt) Radd(left Object) Object {
+	return o.Add(left)
+}
+
+func (o *BigInt) Sub(right Object) Object {
+	if r, ok := asBig(right); ok {
+		return NewBigInt(new(big.Int).Sub(o.Value, r))
+	}
+	if r, ok := right.(*Float); ok {
+		return o.AsFloat().Sub(r)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "-", right.Type())
+}
+
+func (o *BigInt) Rsub(left Object) Object {
+	if l, ok := asBig(left); ok {
+		return NewBigInt(new(big.Int).Sub(l, o.Value))
+	}
+	if l, ok := left.(*Float); ok {
+		return o.AsFloat().Rsub(l)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s %s %s", left.Type(), "-", o.Type())
+}
+
+func (o *BigInt) Mul(right Object) Object {
+	if r, ok := asBig(right); ok {
+		return NewBigInt(new(big.Int).Mul(o.Value, r))
+	}
+	if r, ok := right.(*Float); ok {
+		return o.AsFloat().Mul(r)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "*", right.Type())
+}
+
+func (o *BigInt) Rmul(left Object) Object {
+	return o.Mul(left)
+}
+
+func (o *BigInt) Div(right Object) Object {
+	if r, ok := asBig(right); ok {
+		if r.Sign() == 0 {
+			return NewError(ZERO_DIVISION_ERR, "%s / 0", o.Inspect())
+		}
+		return NewBigInt(new(big.Int).Quo(o.Value, r))
+	}
+	if r, ok := right.(*Float); ok {
+		return o.AsFloat().Div(r)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "/", right.Type())
+}
+
+func (o *BigInt) Rdiv(left Object) Object {
+	if l, ok := asBig(left); ok {
+		return NewBigInt(new(big.Int).Quo(l, o.Value))
+	}
+	if l, ok := left.(*Float); ok {
+		return o.AsFloat().Rdiv(l)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s %s %s", left.Type(), "/", o.Type())
+}
+
+func (o *BigInt) LesserThan(right Object) Object {
+	if r, ok := asBig(right); ok {
+		return &Bool{Value: o.Value.Cmp(r) < 0}
+	}
+	if r, ok := right.(*Float); ok {
+		return o.AsFloat().LesserThan(r)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s and %s not comparable", o.Type(), right.Type())
+}
+
+func (o *BigInt) Equal(right Object) Object {
+	if r, ok := asBig(right); ok {
+		return &Bool{Value: o.Value.Cmp(r) == 0}
+	}
+	if r, ok := right.(*Float); ok {
+		return o.AsFloat().Equal(r)
+	}
+	return NewError(UNSUPPORTED_ERR, "%s and %s not comparable", o.Type(), right.Type())
+}
+
+func (o *BigInt) AsBool() Bool {
+	return Bool{Value: o.Value.Sign() != 0}
+}
@@ -0,0 +1,244 @@
+package object
+
+import (
+	"math"
+	"math/big"
+)
+
+const BUILTIN_OBJ ObjectType = "BUILTIN"
+
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+	// Strict check arguments in strict mode, nil is for math builtins that take no bools.
+	// Go functions registered by host check them against their parameters
+	Strict func(args []Object) Object
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin " + b.Name }
+
+var builtins = map[string]*Builtin{}
+
+func init() {
+	register("abs", abs)
+	register("pow", pow)
+	register("min", minmax("min", func(a, b float64) bool { return a < b }))
+	register("max", minmax("max", func(a, b float64) bool { return a > b }))
+
+	register("sqrt", unary("sqrt", math.Sqrt, func(x float64) float64 { return 0.5 / math.Sqrt(x) }))
+	register("exp", unary("exp", math.Exp, math.Exp))
+	register("ln", unary("ln", math.Log, func(x float64) float64 { return 1 / x }))
+	register("log10", unary("log10", math.Log10, func(x float64) float64 { return 1 / (x * math.Ln10) }))
+	register("sin", unary("sin", math.Sin, math.Cos))
+	register("cos", unary("cos", math.Cos, func(x float64) float64 { return -math.Sin(x) }))
+	register("tan", unary("tan", math.Tan, func(x float64) float64 { return 1 / (math.Cos(x) * math.Cos(x)) }))
+	register("asin", unary("asin", math.Asin, func(x float64) float64 { return 1 / math.Sqrt(1-x*x) }))
+	register("acos", unary("acos", math.Acos, func(x float64) float64 { return -1 / math.Sqrt(1-x*x) }))
+	register("atan", unary("atan", math.Atan, func(x float64) float64 { return 1 / (1 + x*x) }))
+	register("floor", unary("floor", math.Floor, zero))
+	register("ceil", unary("ceil", math.Ceil, zero))
+	register("round", unary("round", math.Round, zero))
+}
+
+// zero is derivative of step functions, they are flat between jumps
+func zero(float64) float64 { return 0 }
+
+func register(name string, fn BuiltinFunction) {
+	builtins[name] = &Builtin{Name: name, Fn: fn}
+}
+
+// Register add builtin, it's for builtins implemented in other packages:
+// grad is in evaluator, it calls ferret functions
+func Register(builtin *Builtin) {
+	builtins[builtin.Name] = builtin
+}
+
+// LookupBuiltin return builtin function by name
+func LookupBuiltin(name string) (*Builtin, bool) {
+	b, ok := builtins[name]
+	return b, ok
+}
+
+// BuiltinNames return names of all builtin functions
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	return names
+}
+
+// AsNative represent numeric object as float64
+func AsNative(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *Float:
+		return obj.Value, true
+	case *Bool:
+		return obj.AsFloat().Value, true
+	case *BigInt:
+		// it's approximate, or ±Inf beyond float range
+		return obj.AsFloat().Value, true
+	}
+	return 0, false
+}
+
+func checkArgs(name string, args []Object, n int) Object {
+	if len(args) != n {
+		return NewError(ARGUMENTS_ERR, "%s: expected %d arguments got %d", name, n, len(args))
+	}
+	for _, arg := range args {
+		if IsError(arg) {
+			return arg
+		}
+	}
+	return nil
+}
+
+// unary is builtin of float function fn, df is its derivative for duals
+func unary(name string, fn, df func(float64) float64) BuiltinFunction {
+	return func(args ...Object) Object {
+		if err := checkArgs(name, args, 1); err != nil {
+			return err
+		}
+		if d, ok := args[0].(*Dual); ok {
+			return d.Apply(fn, df)
+		}
+		x, ok := AsNative(args[0])
+		if !ok {
+			return NewError(UNSUPPORTED_ERR, "%s(%s)", name, args[0].Type())
+		}
+		return &Float{Value: fn(x)}
+	}
+}
+
+func abs(args ...Object) Object {
+	if err := checkArgs("abs", args, 1); err != nil {
+		return err
+	}
+	switch x := args[0].(type) {
+	case *Integer:
+		if x.Value < 0 {
+			return &Integer{Value: -x.Value}
+		}
+		return x
+	case *Float:
+		return &Float{Value: math.Abs(x.Value)}
+	case *BigInt:
+		return x.Abs()
+	case *Dual:
+		if x.Real < 0 {
+			return &Dual{Real: -x.Real, Eps: -x.Eps}
+		}
+		return x
+	}
+	return NewError(UNSUPPORTED_ERR, "abs(%s)", args[0].Type())
+}
+
+func pow(args ...Object) Object {
+	if err := checkArgs("pow", args, 2); err != nil {
+		return err
+	}
+	if res, ok := Power(args[0], args[1]); ok {
+		return res
+	}
+	return NewError(UNSUPPORTED_ERR, "pow(%s, %s)", args[0].Type(), args[1].Type())
+}
+
+// Power is base ^ exp of numbers and duals, ok is false if one of them is not a number
+func Power(base, exp Object) (Object, bool) {
+	_, lDual := base.(*Dual)
+	_, rDual := exp.(*Dual)
+	if lDual || rDual {
+		b, ok := AsDual(base)
+		if !ok {
+			return nil, false
+		}
+		e, ok := AsDual(exp)
+		if !ok {
+			return nil, false
+		}
+		return b.Pow(e), true
+	}
+	// int ^ non-negative int is exact, BigInt when it doesn't fit in int64
+	if e, ok := exp.(*Integer); ok && e.Value >= 0 {
+		if b, ok := base.(*Integer); ok {
+			if res, ok := powInt(b.Value, e.Value); ok {
+				return &Integer{Value: res}, true
+			}
+		}
+		if b, ok := asBig(base); ok {
+			return NewBigInt(new(big.Int).Exp(b, big.NewInt(e.Value), nil)), true
+		}
+	}
+	b, ok := AsNative(base)
+	if !ok {
+		return nil, false
+	}
+	e, ok := AsNative(exp)
+	if !ok {
+		return nil, false
+	}
+	return &Float{Value: math.Pow(b, e)}, true
+}
+
+// powInt is base^exp by squaring, ok is false if it overflows int64
+func powInt(base, exp int64) (int64, bool) {
+	res := int64(1)
+	ok := true
+	for exp > 0 {
+		if exp&1 == 1 {
+			if res, ok = mulInt(res, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return res, true
+}
+
+// mulInt is a * b, ok is false if it overflows int64
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
+}
+
+func minmax(name string, better func(a, b float64) bool) BuiltinFunction {
+	return func(args ...Object) Object {
+		if len(args) == 0 {
+			return NewError(ARGUMENTS_ERR, "%s: expected at least 1 argument", name)
+		}
+		var (
+			best      Object
+			bestValue float64
+		)
+		for _, arg := range args {
+			if IsError(arg) {
+				return arg
+			}
+			v, ok := Real(arg)
+			if !ok {
+				return NewError(UNSUPPORTED_ERR, "%s(%s)", name, arg.Type())
+			}
+			if best == nil || better(v, bestValue) {
+				best, bestValue = arg, v
+			}
+		}
+		return best
+	}
+}
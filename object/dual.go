@@ -0,0 +1,140 @@
+package object
+
+import (
+	"fmt"
+	"math"
+)
+
+const DUAL_OBJ ObjectType = "DUAL"
+
+// Dual is a dual number Real + Eps*ε with ε^2 = 0, arithmetic on it carries derivative:
+// f(x + ε) = f(x) + f'(x)ε. Numbers are duals with zero Eps,
+// comparisons look only at Real, so branches of functions work as for numbers
+type Dual struct {
+	Real float64
+	Eps  float64
+}
+
+func (o *Dual) Type() ObjectType { return DUAL_OBJ }
+func (o *Dual) Inspect() string {
+	if o.Eps < 0 {
+		return fmt.Sprintf("%f - %fε", o.Real, -o.Eps)
+	}
+	return fmt.Sprintf("%f + %fε", o.Real, o.Eps)
+}
+
+// AsDual represent number or dual as dual
+func AsDual(obj Object) (*Dual, bool) {
+	if d, ok := obj.(*Dual); ok {
+		return d, true
+	}
+	v, ok := AsNative(obj)
+	return &Dual{Real: v}, ok
+}
+
+// Real return value of number or real part of dual
+func Real(obj Object) (float64, bool) {
+	if d, ok := obj.(*Dual); ok {
+		return d.Real, true
+	}
+	return AsNative(obj)
+}
+
+func (o *Dual) Add(right Object) Object {
+	r, ok := AsDual(right)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "+", right.Type())
+	}
+	return &Dual{Real: o.Real + r.Real, Eps: o.Eps + r.Eps}
+}
+
+func (o *Dual) Radd(left Object) Object {
+	return o.Add(left)
+}
+
+func (o *Dual) Sub(right Object) Object {
+	r, ok := AsDual(right)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "-", right.Type())
+	}
+	return &Dual{Real: o.Real - r.Real, Eps: o.Eps - r.Eps}
+}
+
+func (o *Dual) Rsub(left Object) Object {
+	l, ok := AsDual(left)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s %s %s", left.Type(), "-", o.Type())
+	}
+	return l.Sub(o)
+}
+
+func (o *Dual) Mul(right Object) Object {
+	r, ok := AsDual(right)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "*", right.Type())
+	}
+	return &Dual{Real: o.Real * r.Real, Eps: o.Eps*r.Real + o.Real*r.Eps}
+}
+
+func (o *Dual) Rmul(left Object) Object {
+	return o.Mul(left)
+}
+
+func (o *Dual) Div(right Object) Object {
+	r, ok := AsDual(right)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s %s %s", o.Type(), "/", right.Type())
+	}
+	return &Dual{Real: o.Real / r.Real, Eps: (o.Eps*r.Real - o.Real*r.Eps) / (r.Real * r.Real)}
+}
+
+func (o *Dual) Rdiv(left Object) Object {
+	l, ok := AsDual(left)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s %s %s", left.Type(), "/", o.Type())
+	}
+	return l.Div(o)
+}
+
+func (o *Dual) LesserThan(right Object) Object {
+	r, ok := Real(right)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s and %s not comparable", o.Type(), right.Type())
+	}
+	return &Bool{Value: o.Real < r}
+}
+
+func (o *Dual) Equal(right Object) Object {
+	r, ok := Real(right)
+	if !ok {
+		return NewError(UNSUPPORTED_ERR, "%s and %s not comparable", o.Type(), right.Type())
+	}
+	return &Bool{Value: o.Real == r}
+}
+
+func (o *Dual) AsBool() Bool {
+	return Bool{Value: o.Real != 0}
+}
+
+// Pow is o ^ exp: exp * o^(exp - 1) * o' + o^exp * ln(o) * exp',
+// terms with zero factors are skipped, so (-2)^2 doesn't need ln(-2)
+// and 0^0 doesn't need 0^-1
+func (o *Dual) Pow(exp *Dual) *Dual {
+	res := &Dual{Real: math.Pow(o.Real, exp.Real)}
+	if o.Eps != 0 && exp.Real != 0 {
+		res.Eps += exp.Real * math.Pow(o.Real, exp.Real-1) * o.Eps
+	}
+	if exp.Eps != 0 {
+		res.Eps += res.Real * math.Log(o.Real) * exp.Eps
+	}
+	return res
+}
+
+// Apply is f(o) where df is derivative of f
+func (o *Dual) Apply(f, df func(float64) float64) *Dual {
+	res := &Dual{Real: f(o.Real)}
+	if o.Eps != 0 {
+		res.Eps = df(o.Real) * o.Eps
+	}
+	return res
+}
@@ -2,12 +2,20 @@ package object
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 )
 
 type Environment struct {
 	outer *Environment
 	env   map[string]Object
+
+	// strict disable bool as integer coercions
+	strict bool
+
+	// frozen environment is read-only and can be shared between goroutines
+	frozen bool
 }
 
 func NewEnv() *Environment {
@@ -24,11 +32,138 @@ func (e *Environment) Get(s string) (Object, bool) {
 	return obj, ok
 }
 
+// Set bind name in environment, it panics if environment is frozen
 func (e *Environment) Set(s string, obj Object) Object {
+	if e.frozen {
+		panic("object: set " + s + " in frozen environment")
+	}
 	e.env[s] = obj
 	return obj
 }
 
+// SetStrict turn on/off strict mode for environment and its sub environments
+func (e *Environment) SetStrict(strict bool) {
+	if e.frozen {
+		panic("object: set strict mode of frozen environment")
+	}
+	e.strict = strict
+}
+
+// Strict report if environment or any of its outer environments are in strict mode
+func (e *Environment) Strict() bool {
+	if e.strict {
+		return true
+	}
+	if e.outer != nil {
+		return e.outer.Strict()
+	}
+	return false
+}
+
+// Names return all names visible from environment
+func (e *Environment) Names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for env := e; env != nil; env = env.outer {
+		for name := range env.env {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Freeze return read-only snapshot of all names visible from environment and its strict mode.
+// Later changes of environment don't affect snapshot, so goroutines can evaluate
+// in their own SubEnv of the same snapshot concurrently. Evaluator and vm make
+// that SubEnv themselves when they are given snapshot.
+// Environments captured by functions and symbolics are frozen too: ones made in
+// environment see snapshot, the others (like closures returned by calls) are copied
+func (e *Environment) Freeze() *Environment {
+	if e.frozen {
+		return e
+	}
+	f := &freezer{
+		from:     e,
+		snapshot: &Environment{env: make(map[string]Object), strict: e.Strict(), frozen: true},
+		copies:   make(map[*Environment]*Environment),
+	}
+	for _, name := range e.Names() {
+		obj, _ := e.Get(name)
+		f.snapshot.env[name] = f.object(obj)
+	}
+	return f.snapshot
+}
+
+// freezer make snapshot that shares nothing mutable with environment it's made from
+type freezer struct {
+	from     *Environment
+	snapshot *Environment
+	// copies of captured environments, closures may share them
+	copies map[*Environment]*Environment
+}
+
+// object return obj with frozen environments of functions and symbolics in it
+func (f *freezer) object(obj Object) Object {
+	switch obj := obj.(type) {
+	case *Function:
+		return &Function{Definition: obj.Definition, Env: f.env(obj.Env), Code: obj.Code}
+	case *Symbolic:
+		return &Symbolic{Expr: obj.Expr, Var: obj.Var, Env: f.env(obj.Env)}
+	case *Vector:
+		var elements []Object
+		for i, el := range obj.Elements {
+			if frozen := f.object(el); frozen != el {
+				if elements == nil {
+					elements = slices.Clone(obj.Elements)
+				}
+				elements[i] = frozen
+			}
+		}
+		if elements != nil {
+			return &Vector{Elements: elements}
+		}
+	}
+	return obj
+}
+
+// env return frozen copy of captured environment
+func (f *freezer) env(env *Environment) *Environment {
+	switch {
+	case env == nil || env.frozen:
+		return env
+	case f.from.sees(env):
+		return f.snapshot
+	}
+	if copied, ok := f.copies[env]; ok {
+		return copied
+	}
+	copied := &Environment{env: make(map[string]Object, len(env.env)), strict: env.strict, frozen: true}
+	// it's recorded before its bindings: functions in it capture it
+	f.copies[env] = copied
+	copied.outer = f.env(env.outer)
+	for name, obj := range env.env {
+		copied.env[name] = f.object(obj)
+	}
+	return copied
+}
+
+// sees report if env is e or one of its outer environments
+func (e *Environment) sees(env *Environment) bool {
+	for ; e != nil; e = e.outer {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Environment) Frozen() bool {
+	return e.frozen
+}
+
 func (e *Environment) SubEnv() *Environment {
 	ne := NewEnv()
 	ne.outer = e
@@ -47,8 +182,9 @@ func (e *Environment) buildString(sb *strings.Builder, level int) int {
 	}
 	indent := strings.Repeat("  ", level)
 	fmt.Fprintf(sb, "%s%s\n", indent, "{")
-	for k, v := range e.env {
-		fmt.Fprintf(sb, "%s  %s: %T %+v\n", indent, k, v, v)
+	for _, k := range slices.Sorted(maps.Keys(e.env)) {
+		v := e.env[k]
+		fmt.Fprintf(sb, "%s  %s: %s = %s\n", indent, k, v.Type(), v.Inspect())
 	}
 	fmt.Fprintf(sb, "%s%s\n", indent, "}")
 	return level + 1
@@ -3,7 +3,10 @@ package object_test
 import (
 	"testing"
 
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/lexer"
 	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/parser"
 )
 
 func TestSubEnvironment(t *testing.T) {
@@ -45,3 +48,118 @@ func TestSubEnvironment(t *testing.T) {
 		t.Errorf("subEnv: 'a' wrong value %d\n", v)
 	}
 }
+
+func TestStrictEnvironment(t *testing.T) {
+	env := object.NewEnv()
+	subEnv := env.SubEnv()
+	if env.Strict() || subEnv.Strict() {
+		t.Fatalf("strict by default\n")
+	}
+
+	env.SetStrict(true)
+	if !subEnv.Strict() {
+		t.Errorf("subEnv: not strict when outer env is strict\n")
+	}
+
+	env.SetStrict(false)
+	subEnv.SetStrict(true)
+	if env.Strict() {
+		t.Errorf("env: strict when only subEnv is strict\n")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	env := object.NewEnv()
+	env.Set("a", &object.Integer{1})
+	env.SetStrict(true)
+	subEnv := env.SubEnv()
+	subEnv.Set("b", &object.Integer{2})
+
+	frozen := subEnv.Freeze()
+	if !frozen.Frozen() || subEnv.Frozen() {
+		t.Fatalf("only snapshot is frozen\n")
+	}
+	if frozen.Freeze() != frozen {
+		t.Errorf("freeze of frozen environment is a copy\n")
+	}
+	if !frozen.Strict() {
+		t.Errorf("frozen: strict mode is lost\n")
+	}
+
+	// snapshot doesn't see later changes
+	env.Set("a", &object.Integer{3})
+	subEnv.Set("c", &object.Integer{4})
+	if a, _ := frozen.Get("a"); a.(*object.Integer).Value != 1 {
+		t.Errorf("frozen: wrong a: %s\n", a.Inspect())
+	}
+	if _, ok := frozen.Get("c"); ok {
+		t.Errorf("frozen: c is set after freeze\n")
+	}
+
+	layer := frozen.SubEnv()
+	layer.Set("a", &object.Integer{5})
+	if a, _ := frozen.Get("a"); a.(*object.Integer).Value != 1 {
+		t.Errorf("frozen: changed by sub environment\n")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("frozen: set doesn't panic\n")
+		}
+	}()
+	frozen.Set("d", &object.Integer{6})
+}
+
+func TestFreezeFunction(t *testing.T) {
+	p := parser.New(lexer.New("fn f() { a }"))
+	program := p.Parse()
+	def := program.Statements[0].(*ast.FunctionStatement)
+
+	env := object.NewEnv()
+	env.Set("a", &object.Integer{1})
+	env.Set("f", &object.Function{Definition: def, Env: env})
+	env.Set("g", &object.Symbolic{Expr: def.Body.Statements[0].(*ast.ExpressionStatement).Expr, Var: "x", Env: env})
+	frozen := env.Freeze()
+
+	// function of snapshot looks names up in snapshot
+	f, _ := frozen.Get("f")
+	if f.(*object.Function).Env != frozen {
+		t.Errorf("frozen: function sees original environment\n")
+	}
+	g, _ := frozen.Get("g")
+	if g.(*object.Symbolic).Env != frozen {
+		t.Errorf("frozen: symbolic sees original environment\n")
+	}
+}
+
+func TestFreezeClosure(t *testing.T) {
+	p := parser.New(lexer.New("fn f() { a + b }"))
+	def := p.Parse().Statements[0].(*ast.FunctionStatement)
+
+	env := object.NewEnv()
+	env.Set("a", &object.Integer{1})
+	// closure returned by call captures environment of the call
+	call := env.SubEnv()
+	call.Set("b", &object.Integer{2})
+	closure := &object.Function{Definition: def, Env: call}
+	env.Set("f", closure)
+	env.Set("fs", &object.Vector{Elements: []object.Object{closure, &object.Integer{3}}})
+	frozen := env.Freeze()
+	call.Set("b", &object.Integer{4})
+
+	f, _ := frozen.Get("f")
+	captured := f.(*object.Function).Env
+	if captured == call || !captured.Frozen() {
+		t.Fatalf("frozen: closure sees original environment\n")
+	}
+	if b, _ := captured.Get("b"); b.(*object.Integer).Value != 2 {
+		t.Errorf("frozen: closure sees later changes: b = %s\n", b.Inspect())
+	}
+	if a, _ := captured.Get("a"); a.(*object.Integer).Value != 1 {
+		t.Errorf("frozen: closure doesn't see snapshot: a = %v\n", a)
+	}
+	fs, _ := frozen.Get("fs")
+	if fn := fs.(*object.Vector).Elements[0].(*object.Function); fn.Env == call || !fn.Env.Frozen() {
+		t.Errorf("frozen: closure in vector sees original environment\n")
+	}
+}
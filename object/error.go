@@ -12,6 +12,15 @@ const (
 	NOT_IMPLEMENTED_ERR  = "not implemented"
 	NOT_FOUND_ERR        = "not found"
 	UNEXPECTED           = "unexpected"
+	ARGUMENTS_ERR        = "wrong arguments"
+	NOT_CALLABLE_ERR     = "not callable"
+	TYPE_ERR             = "type error"
+	ZERO_DIVISION_ERR    = "division by zero"
+	SHAPE_ERR            = "shape mismatch" // vectors of incompatible sizes
+	FUNCTION_ERR         = "function error" // error returned by Go function
+	LIMIT_ERR            = "limit exceeded" // evaluation is cancelled or out of limits
+	RECURSION_ERR        = "recursion too deep"
+	IMPORT_ERR           = "import error"
 )
 
 type Error struct {
@@ -20,7 +29,17 @@ type Error struct {
 }
 
 func (err *Error) Type() ObjectType { return ERROR_OBJ }
-func (err *Error) Inspect() string  { return "[ERROR] " + string(err.ErrType) + ": " + err.msg }
+func (err *Error) Inspect() string  { return "[ERROR] " + err.Error() }
+
+// Error make ferret errors usable as Go errors
+func (err *Error) Error() string { return string(err.ErrType) + ": " + err.msg }
+
+// IsLimitError report if evaluation was stopped by limits or cancellation,
+// unlike other errors it's not a result of program itself
+func IsLimitError(obj Object) bool {
+	err, ok := obj.(*Error)
+	return ok && err.ErrType == LIMIT_ERR
+}
 
 func IsError(obj Object) bool {
 	return obj.Type() == ERROR_OBJ
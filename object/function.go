@@ -0,0 +1,28 @@
+package object
+
+import "github.com/Richtermnd/ferret/ast"
+
+const FUNCTION_OBJ ObjectType = "FUNCTION"
+
+// Function is a closure declared by fn statement,
+// its body is evaluated in sub environment of Env where it's declared
+type Function struct {
+	Definition *ast.FunctionStatement
+	Env        *Environment
+	// Code is body compiled by compiler, vm runs it instead of Definition.
+	// It's nil for functions declared by evaluator
+	Code *Code
+}
+
+// Code is compiled function body with its own constants and names,
+// so it can be run outside of program where function is declared
+type Code struct {
+	Instructions []byte
+	Constants    []Object
+	Names        []string
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string  { return "fn " + f.Definition.Signature() }
+
+func (f *Function) Name() string { return f.Definition.Name.Value }
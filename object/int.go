@@ -68,6 +68,9 @@ func (o *Integer) Rmul(left Object) Object {
 func (o *Integer) Div(right Object) Object {
 	switch right := right.(type) {
 	case *Integer:
+		if right.Value == 0 {
+			return NewError(ZERO_DIVISION_ERR, "%d / 0", o.Value)
+		}
 		return &Integer{Value: o.Value / right.Value}
 	case *Float:
 		return &Float{Value: float64(o.Value) / right.Value}
@@ -79,6 +82,9 @@ func (o *Integer) Div(right Object) Object {
 func (o *Integer) Rdiv(left Object) Object {
 	switch left := left.(type) {
 	case *Integer:
+		if o.Value == 0 {
+			return NewError(ZERO_DIVISION_ERR, "%d / 0", left.Value)
+		}
 		return &Integer{Value: left.Value / o.Value}
 	case *Float:
 		return &Float{Value: left.Value / float64(o.Value)}
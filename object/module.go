@@ -0,0 +1,28 @@
+package object
+
+import "path/filepath"
+
+const MODULE_OBJ ObjectType = "MODULE"
+
+// Module is a file imported by import statement, it's evaluated once in its own Env.
+// Only top-level let bindings and fn declarations are exported (fn f(x) {...}
+// binds f like let does), names bound in blocks and aliases of imports
+// of module itself are not. Any error of module aborts its importer
+type Module struct {
+	Path    string // absolute path of file
+	Env     *Environment
+	Exports []string
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "module " + filepath.Base(m.Path) }
+
+// Get return exported binding
+func (m *Module) Get(name string) (Object, bool) {
+	for _, export := range m.Exports {
+		if export == name {
+			return m.Env.Get(name)
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,20 @@
+package object
+
+import (
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/format"
+)
+
+const SYMBOLIC_OBJ ObjectType = "SYMBOLIC"
+
+// Symbolic is an expression of variable Var made by d(expr, x), like a function
+// of one argument its other names are looked up in Env where it's made.
+// Expr can be evaluated in any environment that binds its names
+type Symbolic struct {
+	Expr ast.Expression
+	Var  string
+	Env  *Environment
+}
+
+func (s *Symbolic) Type() ObjectType { return SYMBOLIC_OBJ }
+func (s *Symbolic) Inspect() string  { return format.Node(s.Expr) }
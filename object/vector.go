@@ -0,0 +1,25 @@
+package object
+
+import "strings"
+
+const VECTOR_OBJ ObjectType = "VECTOR"
+
+// Vector is a sequence of objects, matrix is a vector of vectors of the same length.
+// Arithmetic on vectors is in evaluator, it needs operators of elements
+type Vector struct {
+	Elements []Object
+}
+
+func (o *Vector) Type() ObjectType { return VECTOR_OBJ }
+func (o *Vector) Inspect() string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, el := range o.Elements {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(el.Inspect())
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
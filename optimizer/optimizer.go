@@ -0,0 +1,327 @@
+// Package optimizer rewrites ast before evaluation: it folds constant expressions
+// (2 * 3.5, sqrt(2), 0 < 1 < 2), simplifies boolean algebra (x and true is x for bool x)
+// and eliminates dead branches of if statements with constant conditions.
+// There are no loops yet, so hoisting invariants means that calls of builtins
+// with constant arguments are computed once here instead of every evaluation.
+//
+// Constant expressions are computed with evaluator operations, so results are the same.
+// Factorials and powers of integers bigger than int64 are left for evaluation within its limits.
+// Expressions that always fail like 1/0 are reported as diagnostics and left as is
+package optimizer
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+type Options struct {
+	// Strict is strict mode at the start of program, like -strict flag
+	Strict bool
+
+	// Bound report if name is bound before program runs, such names shadow builtins.
+	// nil means that nothing is bound
+	Bound func(name string) bool
+}
+
+type optimizer struct {
+	opts Options
+
+	// assigned is names of all let statements, functions, their parameters
+	// and imports, they may shadow builtins
+	assigned map[string]bool
+
+	diagnostics []diag.Diagnostic
+}
+
+// Optimize return optimized program, unchanged nodes are shared with the original one
+func Optimize(program *ast.Program, opts Options) (*ast.Program, []diag.Diagnostic) {
+	o := &optimizer{opts: opts, assigned: make(map[string]bool)}
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			o.assigned[n.Name.Value] = true
+		case *ast.FunctionStatement:
+			o.assigned[n.Name.Value] = true
+			for _, param := range n.Parameters {
+				o.assigned[param.Name.Value] = true
+			}
+		case *ast.ImportStatement:
+			o.assigned[n.Alias.Value] = true
+		}
+		return true
+	})
+	optimized := &ast.Program{
+		Statements: o.statements(program.Statements, opts.Strict),
+		End:        program.End,
+	}
+	return optimized, o.diagnostics
+}
+
+func (o *optimizer) statements(stmts []ast.Statement, strict bool) []ast.Statement {
+	res := make([]ast.Statement, 0, len(stmts))
+	for _, stmt := range stmts {
+		if pragma, ok := stmt.(*ast.PragmaStatement); ok && pragma.Name == "strict" {
+			strict = true
+		}
+		if stmt := o.statement(stmt, strict); stmt != nil {
+			res = append(res, stmt)
+		}
+	}
+	return res
+}
+
+// statement return optimized statement or nil if it's eliminated
+func (o *optimizer) statement(stmt ast.Statement, strict bool) ast.Statement {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		return &ast.ExpressionStatement{Token: stmt.Token, Expr: o.expression(stmt.Expr, strict)}
+	case *ast.LetStatement:
+		return &ast.LetStatement{Token: stmt.Token, Name: stmt.Name, Type: stmt.Type, Value: o.expression(stmt.Value, strict)}
+	case *ast.BlockStatement:
+		return o.block(stmt, strict)
+	case *ast.IfStatement:
+		condition := o.expression(stmt.Condition, strict)
+		if b, ok := condition.(*ast.BooleanLiteral); ok {
+			switch {
+			case b.Value:
+				return o.block(stmt.Consequence, strict)
+			case stmt.Alternative != nil:
+				return o.block(stmt.Alternative, strict)
+			}
+			return nil
+		}
+		res := &ast.IfStatement{Token: stmt.Token, Condition: condition, Consequence: o.block(stmt.Consequence, strict)}
+		if stmt.Alternative != nil {
+			res.Alternative = o.block(stmt.Alternative, strict)
+		}
+		return res
+	case *ast.FunctionStatement:
+		// body is left as is: strict mode of call may differ from the one of declaration
+		return stmt
+	}
+	return stmt
+}
+
+func (o *optimizer) block(block *ast.BlockStatement, strict bool) *ast.BlockStatement {
+	return &ast.BlockStatement{Token: block.Token, Statements: o.statements(block.Statements, strict), End: block.End}
+}
+
+func (o *optimizer) expression(expr ast.Expression, strict bool) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.PrefixExpression:
+		res := &ast.PrefixExpression{Token: expr.Token, Operator: expr.Operator, Right: o.expression(expr.Right, strict)}
+		if right, ok := constant(res.Right); ok {
+			return o.fold(res, evaluator.Prefix(res.Operator, right, strict))
+		}
+		// !!x is x for bool x
+		if inner, ok := res.Right.(*ast.PrefixExpression); ok && res.Operator == "!" && inner.Operator == "!" && isBool(inner.Right) {
+			return inner.Right
+		}
+		return res
+
+	case *ast.PostfixExpression:
+		res := &ast.PostfixExpression{Token: expr.Token, Operator: expr.Operator, Left: o.expression(expr.Left, strict)}
+		if left, ok := constant(res.Left); ok && !tooBig(res.Operator, left, nil) {
+			return o.fold(res, evaluator.Postfix(res.Operator, left, strict))
+		}
+		return res
+
+	case *ast.InfixExpression:
+		res := &ast.InfixExpression{
+			Token:    expr.Token,
+			Operator: expr.Operator,
+			Left:     o.expression(expr.Left, strict),
+			Right:    o.expression(expr.Right, strict),
+		}
+		left, lok := constant(res.Left)
+		right, rok := constant(res.Right)
+		if lok && rok && !tooBig(res.Operator, left, right) {
+			return o.fold(res, evaluator.Infix(res.Token, left, right, strict))
+		}
+		return simplify(res)
+
+	case *ast.ComparisonChain:
+		res := &ast.ComparisonChain{Token: expr.Token, Operators: expr.Operators}
+		// folded while all operands are constants
+		folded := true
+		var prev object.Object
+		for i, operand := range expr.Operands {
+			operand = o.expression(operand, strict)
+			res.Operands = append(res.Operands, operand)
+			value, ok := constant(operand)
+			folded = folded && ok
+			if !ok {
+				prev = nil
+				continue
+			}
+			if prev != nil {
+				cmp := evaluator.Infix(res.Operators[i-1], prev, value, strict)
+				if object.IsError(cmp) || !evaluator.IsTrue(cmp) {
+					// the rest is never evaluated, so it isn't folded or reported: 1 > 2 > 1/0 is false
+					res.Operands = append(res.Operands, expr.Operands[i+1:]...)
+					if !folded {
+						return res
+					}
+					if !object.IsError(cmp) {
+						cmp = evaluator.FALSE
+					}
+					return o.fold(res, cmp)
+				}
+			}
+			prev = value
+		}
+		if !folded {
+			return res
+		}
+		return o.fold(res, evaluator.TRUE)
+
+	case *ast.CallExpression:
+		if o.isDerive(expr) {
+			// d(expr, x) is differentiated with real numbers, 1 / 2 there isn't 0
+			return expr
+		}
+		res := &ast.CallExpression{Token: expr.Token, Function: o.expression(expr.Function, strict), Piped: expr.Piped}
+		args := make([]object.Object, 0, len(expr.Arguments))
+		for _, arg := range expr.Arguments {
+			arg = o.expression(arg, strict)
+			res.Arguments = append(res.Arguments, arg)
+			if value, ok := constant(arg); ok {
+				args = append(args, value)
+			}
+		}
+		builtin, ok := o.builtin(res.Function)
+		if !ok || len(args) != len(res.Arguments) || (builtin.Name == "pow" && len(args) == 2 && tooBig("^", args[0], args[1])) {
+			return res
+		}
+		return o.fold(res, evaluator.Call(builtin, args, strict))
+
+	case *ast.VectorLiteral:
+		res := &ast.VectorLiteral{Token: expr.Token, End: expr.End}
+		for _, el := range expr.Elements {
+			res.Elements = append(res.Elements, o.expression(el, strict))
+		}
+		return res
+	}
+	return expr
+}
+
+// builtin return builtin function if expr is its name that can't be shadowed
+func (o *optimizer) builtin(expr ast.Expression) (*object.Builtin, bool) {
+	ident, ok := expr.(*ast.Identifier)
+	if !ok || o.assigned[ident.Value] || (o.opts.Bound != nil && o.opts.Bound(ident.Value)) {
+		return nil, false
+	}
+	return object.LookupBuiltin(ident.Value)
+}
+
+// isDerive report if call is d(expr, x) and d can't be bound
+func (o *optimizer) isDerive(call *ast.CallExpression) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == "d" && !o.assigned["d"] && (o.opts.Bound == nil || !o.opts.Bound("d"))
+}
+
+// fold replace expr with literal of its value,
+// errors are reported and objects without literals (25!) are left as is
+func (o *optimizer) fold(expr ast.Expression, value object.Object) ast.Expression {
+	if err, ok := value.(*object.Error); ok {
+		o.diagnostics = append(o.diagnostics, diag.Errorf(ast.Span(expr), "%s", err.Error()))
+		return expr
+	}
+	pos := ast.Span(expr).Start
+	switch value := value.(type) {
+	case *object.Integer:
+		tok := token.Token{Type: token.INT, Literal: strconv.FormatInt(value.Value, 10), Pos: pos}
+		return &ast.IntegerLiteral{Token: tok, Value: value.Value}
+	case *object.Float:
+		// inf and nan have no literals
+		if math.IsInf(value.Value, 0) || math.IsNaN(value.Value) {
+			return expr
+		}
+		tok := token.Token{Type: token.FLOAT, Literal: strconv.FormatFloat(value.Value, 'g', -1, 64), Pos: pos}
+		return &ast.FloatLiteral{Token: tok, Value: value.Value}
+	case *object.Bool:
+		tok := token.NoLiteralToken(token.FALSE)
+		if value.Value {
+			tok = token.NoLiteralToken(token.TRUE)
+		}
+		tok.Pos = pos
+		return &ast.BooleanLiteral{Token: tok, Value: value.Value}
+	}
+	return expr
+}
+
+// tooBig report if factorial or power of integers doesn't fit in integer literal.
+// It isn't computed here, evaluation does it within its limits: 3000000! can take minutes
+func tooBig(op string, left, right object.Object) bool {
+	l, ok := left.(*object.Integer)
+	if !ok {
+		return false
+	}
+	switch op {
+	case "!":
+		// 21! is bigger than int64
+		return l.Value > 20
+	case "^":
+		r, ok := right.(*object.Integer)
+		return ok && r.Value > 0 && float64(r.Value)*math.Log2(math.Abs(float64(l.Value))) >= 63
+	}
+	return false
+}
+
+// simplify remove neutral operands of and/or with bool expression: x and true, false or x
+func simplify(expr *ast.InfixExpression) ast.Expression {
+	var neutral bool
+	switch expr.Token.Type {
+	case token.AND:
+		neutral = true
+	case token.OR:
+		neutral = false
+	default:
+		return expr
+	}
+	if b, ok := expr.Right.(*ast.BooleanLiteral); ok && b.Value == neutral && isBool(expr.Left) {
+		return expr.Left
+	}
+	if b, ok := expr.Left.(*ast.BooleanLiteral); ok && b.Value == neutral && isBool(expr.Right) {
+		return expr.Right
+	}
+	return expr
+}
+
+// isBool report if expression is always bool or error
+func isBool(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.BooleanLiteral, *ast.ComparisonChain:
+		return true
+	case *ast.PrefixExpression:
+		return expr.Operator == "!"
+	case *ast.InfixExpression:
+		switch expr.Token.Type {
+		case token.AND, token.OR, token.EQ, token.NEQ, token.LT, token.LEQ, token.GT, token.GEQ:
+			return true
+		}
+	}
+	return false
+}
+
+// constant return value of literal
+func constant(expr ast.Expression) (object.Object, bool) {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: expr.Value}, true
+	case *ast.FloatLiteral:
+		return &object.Float{Value: expr.Value}, true
+	case *ast.BooleanLiteral:
+		if expr.Value {
+			return evaluator.TRUE, true
+		}
+		return evaluator.FALSE, true
+	}
+	return nil, false
+}
@@ -0,0 +1,176 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/optimizer"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/token"
+)
+
+func parse(t *testing.T, source string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.Parse()
+	if p.HasErrors() {
+		t.Fatal(p.Errors())
+	}
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{source: "2 * 3.5", expected: "7"},
+		{source: "1 + 2 * 3 - x", expected: "(7 - x)"},
+		{source: "x + 2 * 3", expected: "(x + 6)"},
+		{source: "-(2 + 3)", expected: "-5"},
+		{source: "5! + 50%", expected: "120.5"},
+		{source: "25!", expected: "(25!)"},
+		{source: "20!", expected: "2432902008176640000"},
+		{source: "2 ^ 62", expected: "4611686018427387904"},
+		{source: "3 ^ 40", expected: "(3 ^ 40)"},
+		{source: "pow(3, 40)", expected: "pow(3, 40)"},
+		{source: "2x", expected: "(2 * x)"},
+		{source: "sqrt(16) * x", expected: "(4 * x)"},
+		{source: "16 |> sqrt", expected: "4"},
+		{source: "let sqrt = 1\nsqrt(16)", expected: "let sqrt = 1sqrt(16)"},
+		{source: "max(1, x)", expected: "max(1, x)"},
+		{source: "0 < 1 <= 1", expected: "true"},
+		{source: "0 < 2 < 1", expected: "false"},
+		{source: "0 < x < 1", expected: "(0 < x < 1)"},
+		{source: "1 > 2 > 1/0", expected: "false"},
+		{source: "x < 1 > 2 > 3 + 4", expected: "(x < 1 > 2 > (3 + 4))"},
+		{source: "1 < 2 and 3 > 4", expected: "false"},
+		{source: "x > 0 and true", expected: "(x > 0)"},
+		{source: "false or x == 1", expected: "(x == 1)"},
+		{source: "x and true", expected: "(x and true)"},
+		{source: "x > 0 or true", expected: "((x > 0) or true)"},
+		{source: "!!(x > 0)", expected: "(x > 0)"},
+		{source: "!!x", expected: "(!(!x))"},
+		{source: "!(1 > 2)", expected: "true"},
+		{source: "5 + true", expected: "6"},
+		{source: "{ let a = 1 + 1 }\na", expected: "{ let a = 2; }a"},
+		{source: "1.0 / 0", expected: "(1.0 / 0)"},
+		{source: "d(1 / 2 * x^2, x)", expected: "d(((1 / 2) * (x ^ 2)), x)"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			program := parse(t, tt.source)
+			original := program.String()
+			optimized, diagnostics := optimizer.Optimize(program, optimizer.Options{})
+			if len(diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostics: %v", diagnostics)
+			}
+			if res := optimized.String(); res != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, res)
+			}
+			if program.String() != original {
+				t.Errorf("original program is changed: %s", program)
+			}
+			env := object.NewEnv()
+			env.Set("x", &object.Float{Value: 0.5})
+			expected := evaluator.Eval(env, program)
+			env = object.NewEnv()
+			env.Set("x", &object.Float{Value: 0.5})
+			if res := evaluator.Eval(env, optimized); res.Inspect() != expected.Inspect() {
+				t.Errorf("result is changed: expected %s got %s", expected.Inspect(), res.Inspect())
+			}
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	program := parse(t, "5 + true\n{ #strict\n 5 + true; 1 + 1 }\n5 + true")
+	optimized, diagnostics := optimizer.Optimize(program, optimizer.Options{})
+	if expected := "6{ #strict; (5 + true); 2; }6"; optimized.String() != expected {
+		t.Errorf("expected %s got %s", expected, optimized)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Error() != "3:2: type error: INTEGER + BOOL: bool is not a number" {
+		t.Errorf("wrong diagnostics: %v", diagnostics)
+	}
+
+	_, diagnostics = optimizer.Optimize(parse(t, "5 + true"), optimizer.Options{Strict: true})
+	if len(diagnostics) != 1 {
+		t.Errorf("expected error in strict mode got %v", diagnostics)
+	}
+}
+
+func TestBound(t *testing.T) {
+	bound := func(name string) bool { return name == "sqrt" }
+	optimized, _ := optimizer.Optimize(parse(t, "sqrt(4) + abs(-1)"), optimizer.Options{Bound: bound})
+	if expected := "(sqrt(4) + 1)"; optimized.String() != expected {
+		t.Errorf("expected %s got %s", expected, optimized)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected []string
+	}{
+		{source: "1/0", expected: []string{"1:1: division by zero: 1 / 0"}},
+		{source: "let a = x + 2 * (3 / (1 - 1))", expected: []string{"1:18: division by zero: 3 / 0"}},
+		{source: "(-1)!\nsqrt(1, 2)", expected: []string{
+			"1:2: unsupported: factorial of negative integer -1",
+			"2:1: wrong arguments: sqrt: expected 1 arguments got 2",
+		}},
+		{source: "5!/0", expected: []string{"1:1: division by zero: 120 / 0"}},
+		{source: "1 < 2 < 1/0", expected: []string{"1:9: division by zero: 1 / 0"}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			program := parse(t, tt.source)
+			optimized, diagnostics := optimizer.Optimize(program, optimizer.Options{})
+			if len(diagnostics) != len(tt.expected) {
+				t.Fatalf("expected %v got %v", tt.expected, diagnostics)
+			}
+			for i, d := range diagnostics {
+				if d.Error() != tt.expected[i] {
+					t.Errorf("expected %s got %s", tt.expected[i], d.Error())
+				}
+			}
+			// failing expression is left for evaluation
+			if res := evaluator.Eval(object.NewEnv(), optimized); !object.IsError(res) {
+				t.Errorf("expected error got %s", res.Inspect())
+			}
+		})
+	}
+}
+
+func TestDeadBranches(t *testing.T) {
+	block := func(value int64) *ast.BlockStatement {
+		return &ast.BlockStatement{Statements: []ast.Statement{
+			&ast.ExpressionStatement{Expr: &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: value}},
+		}}
+	}
+	condition := func(op token.TokenType) ast.Expression {
+		return &ast.InfixExpression{
+			Token:    token.NoLiteralToken(op),
+			Operator: token.NoLiteralToken(op).Literal,
+			Left:     &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1},
+			Right:    &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2},
+		}
+	}
+	testCases := []struct {
+		stmt     *ast.IfStatement
+		expected string
+	}{
+		{stmt: &ast.IfStatement{Condition: condition(token.LT), Consequence: block(1), Alternative: block(2)}, expected: "{ 1; }"},
+		{stmt: &ast.IfStatement{Condition: condition(token.GT), Consequence: block(1), Alternative: block(2)}, expected: "{ 1; }"},
+		{stmt: &ast.IfStatement{Condition: condition(token.GT), Consequence: block(1)}, expected: ""},
+		{stmt: &ast.IfStatement{Condition: &ast.Identifier{Value: "x"}, Consequence: block(1)}, expected: "if x { 1; }"},
+	}
+	for _, tt := range testCases {
+		optimized, _ := optimizer.Optimize(&ast.Program{Statements: []ast.Statement{tt.stmt}}, optimizer.Options{})
+		if optimized.String() != tt.expected {
+			t.Errorf("%s: expected %q got %q", tt.stmt, tt.expected, optimized)
+		}
+	}
+}
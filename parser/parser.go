@@ -3,9 +3,12 @@ package parser
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/diag"
 	"github.com/Richtermnd/ferret/lexer"
 	"github.com/Richtermnd/ferret/token"
 )
@@ -20,10 +23,34 @@ type Parser struct {
 	l         *lexer.Lexer
 	peekToken token.Token
 
+	// peekNewline true if there was a line break between cur and peek tokens
+	peekNewline bool
+
+	// curNewline true if there was a line break before cur token
+	curNewline bool
+
+	// next is the token after peek, read ahead only to tell percent from remainder
+	next        *token.Token
+	nextNewline bool
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 
-	errors []error
+	diagnostics []diag.Diagnostic
+
+	// recovering true after error until parser skip to the end of statement,
+	// errors are not reported meanwhile, they are most likely caused by the first one
+	recovering bool
+
+	// depth is a number of blocks parser is in
+	depth int
+
+	// resume true if statement recovery stopped at a token that is not a part of broken statement:
+	// '}' that closes current block or the start of the next statement
+	resume bool
+
+	// incomplete true if source ended where more input was expected
+	incomplete bool
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -44,14 +71,15 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns[token.FALSE] = p.parseBooleanLiteral
 
 	p.prefixParseFns[token.LPAREN] = p.parseGroupedExpression
+	p.prefixParseFns[token.LBRACKET] = p.parseVectorLiteral
 
 	// --- infix ---
 	p.infixParseFns[token.ADD] = p.parseInfixExpression
 	p.infixParseFns[token.SUB] = p.parseInfixExpression
 	p.infixParseFns[token.MUL] = p.parseInfixExpression
 	p.infixParseFns[token.DIV] = p.parseInfixExpression
-	p.infixParseFns[token.REM] = p.parseInfixExpression
-	p.infixParseFns[token.REM] = p.parseInfixExpression
+	p.infixParseFns[token.REM] = p.parseRemOrPercent
+	p.infixParseFns[token.POW] = p.parsePowerExpression
 
 	p.infixParseFns[token.EQ] = p.parseInfixExpression
 	p.infixParseFns[token.NEQ] = p.parseInfixExpression
@@ -61,31 +89,113 @@ func New(l *lexer.Lexer) *Parser {
 	p.infixParseFns[token.LEQ] = p.parseInfixExpression
 	p.infixParseFns[token.OR] = p.parseInfixExpression
 	p.infixParseFns[token.AND] = p.parseInfixExpression
+
+	// --- postfix ---
+	p.infixParseFns[token.NOT] = p.parsePostfixExpression
+
+	p.infixParseFns[token.LPAREN] = p.parseCallExpression
+	p.infixParseFns[token.DOT] = p.parseSelectorExpression
+	p.infixParseFns[token.PIPE] = p.parsePipeExpression
 	p.nextToken()
 	p.nextToken()
 	return p
 }
 
+// Parse parse the whole source. On error parser skip to the end of statement
+// and continue, so all statements with errors are reported and left out of program
 func (p *Parser) Parse() *ast.Program {
 	program := new(ast.Program)
 	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
-		if !p.HasErrors() {
+		if stmt := p.parseStatement(); stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
+		if p.resume {
+			p.resume = false
+			continue
+		}
 		p.nextToken()
 	}
+	program.End = p.curToken
+	p.lexerDiagnostics()
 	return program
 }
 
+// lexerDiagnostics merge diagnostics of lexer with parser ones by position,
+// unterminated comment means that input is incomplete
+func (p *Parser) lexerDiagnostics() {
+	diagnostics := p.l.Diagnostics()
+	if len(diagnostics) == 0 {
+		return
+	}
+	if len(p.diagnostics) == 0 || p.incomplete {
+		p.incomplete = true
+	}
+	p.diagnostics = append(p.diagnostics, diagnostics...)
+	sort.SliceStable(p.diagnostics, func(i, j int) bool {
+		return p.diagnostics[i].Span.Start.Less(p.diagnostics[j].Span.Start)
+	})
+}
+
+// parseStatement return nil if statement has errors
 func (p *Parser) parseStatement() ast.Statement {
+	before := len(p.diagnostics)
+	start := p.curToken
+	stmt := p.parseStatementKind()
+	if p.recovering {
+		p.synchronize(start)
+	}
+	if len(p.diagnostics) > before {
+		return nil
+	}
+	return stmt
+}
+
+// synchronize skip tokens until the end of broken statement:
+// ';', line break or '}' that closes current block
+func (p *Parser) synchronize(start token.Token) {
+	p.recovering = false
+	// error is found at the start of the next statement: 1 + \n let a = 1
+	if p.curNewline && p.curToken.Pos != start.Pos && startsStatement(p.curToken) {
+		p.resume = true
+		return
+	}
+	for {
+		switch {
+		case p.curToken.Is(token.SEMICOLON), p.curToken.Is(token.EOF):
+			return
+		case p.curToken.Is(token.RBRACE) && p.depth > 0:
+			p.resume = true
+			return
+		case p.peekNewline, p.peekToken.Is(token.EOF), p.peekToken.Is(token.RBRACE) && p.depth > 0:
+			return
+		}
+		p.nextToken()
+	}
+}
+
+// startsStatement report if token can only start a statement
+func startsStatement(tok token.Token) bool {
+	switch tok.Type {
+	case token.LET, token.FN, token.IMPORT, token.LBRACE, token.PRAGMA:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseStatementKind() ast.Statement {
 	// Here will be other tokens like var, functions declarations assignment and other
 	// Everything other - expressions
 	switch p.curToken.Type {
 	case token.LET:
 		return p.parseLetStatement()
+	case token.FN:
+		return p.parseFunctionStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
 	case token.LBRACE:
 		return p.parseBlockStatement()
+	case token.PRAGMA:
+		return p.parsePragmaStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -100,29 +210,180 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	p.nextToken()
 	if !p.curToken.Is(token.IDENT) {
-		p.errors = append(p.errors, fmt.Errorf("expected identifier, got %v", p.curToken.Type))
+		p.report(p.curToken, "let: expected name, got "+describe(p.curToken), "let name = value")
 		return nil
 	}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	p.nextToken()
+	if p.curToken.Is(token.COLON) {
+		p.nextToken()
+		if stmt.Type = p.parseTypeName(); stmt.Type == nil {
+			return nil
+		}
+		p.nextToken()
+	}
 	if !p.curToken.Is(token.ASSIGN) {
-		p.errors = append(p.errors, fmt.Errorf("expected =, got %v", p.curToken.Type))
+		p.report(p.curToken, "let: expected =, got "+describe(p.curToken), "let name = value")
+		return nil
 	}
 	p.nextToken()
 	stmt.Value = p.parseExpression(token.LOWEST)
 	return stmt
 }
 
+// parseTypeName parse annotation at current token
+func (p *Parser) parseTypeName() *ast.TypeName {
+	if !p.curToken.Is(token.IDENT) {
+		p.report(p.curToken, "expected type, got "+describe(p.curToken), typesHint)
+		return nil
+	}
+	if !token.IsTypeName(p.curToken.Literal) {
+		p.report(p.curToken, "unknown type "+p.curToken.Literal, typesHint)
+		return nil
+	}
+	return &ast.TypeName{Token: p.curToken, Name: p.curToken.Literal}
+}
+
+const typesHint = "types are int, float, bool and vector"
+
+func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	const usage = "fn name(a: float, b) -> float { ... }"
+	stmt := &ast.FunctionStatement{Token: p.curToken}
+
+	p.nextToken()
+	if !p.curToken.Is(token.IDENT) {
+		p.report(p.curToken, "fn: expected name, got "+describe(p.curToken), usage)
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
+	if !p.curToken.Is(token.LPAREN) {
+		p.report(p.curToken, "fn: expected (, got "+describe(p.curToken), usage)
+		return nil
+	}
+	if stmt.Parameters = p.parseParameters(); p.recovering {
+		return nil
+	}
+	p.nextToken()
+	if p.curToken.Is(token.ARROW) {
+		p.nextToken()
+		if stmt.ReturnType = p.parseTypeName(); stmt.ReturnType == nil {
+			return nil
+		}
+		p.nextToken()
+	}
+	if !p.curToken.Is(token.LBRACE) {
+		p.report(p.curToken, "fn: expected {, got "+describe(p.curToken), usage)
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+	if stmt.Body == nil {
+		return nil
+	}
+	if p.peekToken.Is(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	const usage = `import "stats.fe" as s`
+	stmt := &ast.ImportStatement{Token: p.curToken}
+	if p.depth > 0 {
+		p.report(p.curToken, "import inside of block", "imports are allowed only at top level of file")
+		return nil
+	}
+
+	p.nextToken()
+	if !p.curToken.Is(token.STRING) {
+		p.report(p.curToken, "import: expected path, got "+describe(p.curToken), usage)
+		return nil
+	}
+	stmt.Path = p.curToken
+	p.nextToken()
+	if !p.curToken.Is(token.AS) {
+		p.report(p.curToken, "import: expected as, got "+describe(p.curToken), usage)
+		return nil
+	}
+	p.nextToken()
+	if !p.curToken.Is(token.IDENT) {
+		p.report(p.curToken, "import: expected name, got "+describe(p.curToken), usage)
+		return nil
+	}
+	stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekToken.Is(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parseParameters parse parameters of function until ')'
+func (p *Parser) parseParameters() []*ast.Parameter {
+	open := p.curToken
+	var params []*ast.Parameter
+	if p.peekToken.Is(token.RPAREN) {
+		p.nextToken()
+		return params
+	}
+	for {
+		p.nextToken()
+		if !p.curToken.Is(token.IDENT) {
+			p.report(p.curToken, "fn: expected parameter name, got "+describe(p.curToken), "")
+			return nil
+		}
+		param := &ast.Parameter{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if p.peekToken.Is(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			if param.Type = p.parseTypeName(); param.Type == nil {
+				return nil
+			}
+		}
+		params = append(params, param)
+		if !p.peekToken.Is(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.peekToken.Is(token.RPAREN) {
+		p.report(p.peekToken, "expected , or ), got "+describe(p.peekToken), "( is opened at "+open.Pos.String())
+		return nil
+	}
+	p.nextToken()
+	return params
+}
+
+func (p *Parser) parsePragmaStatement() *ast.PragmaStatement {
+	stmt := &ast.PragmaStatement{Token: p.curToken, Name: p.curToken.Literal}
+	if p.peekToken.Is(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{
 		Token: p.curToken,
 	}
+	p.depth++
+	defer func() { p.depth-- }()
+
 	p.nextToken()
 	for !p.curToken.Is(token.RBRACE) {
-		stmt := p.parseStatement()
-		block.Statements = append(block.Statements, stmt)
+		if p.curToken.Is(token.EOF) {
+			p.report(p.curToken, "no closing }", "{ is opened at "+block.Token.Pos.String())
+			return nil
+		}
+		if stmt := p.parseStatement(); stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		if p.resume {
+			p.resume = false
+			continue
+		}
 		p.nextToken()
 	}
+	block.End = p.curToken
 	return block
 }
 
@@ -135,12 +396,23 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefixParser, ok := p.prefixParseFns[p.curToken.Type]
 	if !ok {
-		p.errors = append(p.errors, fmt.Errorf("no prefix parsers for %s", p.curToken.Literal))
+		p.unexpected(p.curToken)
 		return nil
 	}
 
 	leftExp := prefixParser()
-	for (!p.peekToken.Is(token.LF) || !p.peekToken.Is(token.SEMICOLON)) && precedence < p.peekPrecedence() {
+	for (!p.peekToken.Is(token.LF) || !p.peekToken.Is(token.SEMICOLON)) && precedence < p.peekPrecedence() || p.isJuxtaposition(leftExp) {
+		if p.isJuxtaposition(leftExp) {
+			if precedence >= token.IMPLICIT {
+				break
+			}
+			leftExp = p.parseImplicitMultiplication(leftExp)
+			continue
+		}
+		// call and factorial can't start on the next line, it's a new statement
+		if p.peekNewline && (p.peekToken.Is(token.LPAREN) || p.peekToken.Is(token.NOT)) {
+			break
+		}
 		infix, ok := p.infixParseFns[p.peekToken.Type]
 		if !ok {
 			return leftExp
@@ -161,7 +433,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
 	if err != nil {
-		p.errors = append(p.errors, fmt.Errorf("not a valid int %s", p.curToken.Literal))
+		p.report(p.curToken, "not a valid int "+p.curToken.Literal, "")
 		return nil
 	}
 	lit.Value = value
@@ -175,7 +447,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		p.errors = append(p.errors, fmt.Errorf("not a valid float %s", p.curToken.Literal))
+		p.report(p.curToken, "not a valid float "+p.curToken.Literal, "")
 		return nil
 	}
 	lit.Value = value
@@ -206,43 +478,329 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	precedence := p.curPrecedence()
 	p.nextToken()
 	exp.Right = p.parseExpression(precedence)
+	if exp.Token.IsComparison() && p.peekToken.IsComparison() && !p.peekNewline {
+		return p.parseComparisonChain(exp)
+	}
 	return exp
 }
 
+// parseComparisonChain continue first comparison in chain: 0 < x <= 10
+func (p *Parser) parseComparisonChain(first *ast.InfixExpression) ast.Expression {
+	chain := &ast.ComparisonChain{
+		Token:     first.Token,
+		Operands:  []ast.Expression{first.Left, first.Right},
+		Operators: []token.Token{first.Token},
+	}
+	for p.peekToken.IsComparison() && !p.peekNewline {
+		p.nextToken()
+		op := p.curToken
+		precedence := p.curPrecedence()
+		p.nextToken()
+		chain.Operators = append(chain.Operators, op)
+		chain.Operands = append(chain.Operands, p.parseExpression(precedence))
+	}
+	return chain
+}
+
+// parsePowerExpression parse right operand of '^' with lower precedence,
+// so the next '^' belongs to it: 2^3^2 is 2^(3^2).
+// Juxtaposition belongs to it too: e^2x is e^(2x)
+func (p *Parser) parsePowerExpression(left ast.Expression) ast.Expression {
+	exp := &ast.InfixExpression{
+		Token:    p.curToken,
+		Left:     left,
+		Operator: p.curToken.Literal,
+	}
+	p.nextToken()
+	exp.Right = p.parseExpression(token.IMPLICIT - 1)
+	return exp
+}
+
+func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	return &ast.PostfixExpression{
+		Token:    p.curToken,
+		Left:     left,
+		Operator: p.curToken.Literal,
+	}
+}
+
+// parseRemOrPercent decide what '%' is.
+// It's a postfix percent if nothing that can start an operand follows on the same line
+// (50% + 1, (x)%) or it's an operator that can be binary (50% - 10, 50%!),
+// and a remainder otherwise (7 % 2, 7 % (-2))
+func (p *Parser) parseRemOrPercent(left ast.Expression) ast.Expression {
+	if p.isPercent(p.peekToken, p.peekNewline) {
+		return p.parsePostfixExpression(left)
+	}
+	return p.parseInfixExpression(left)
+}
+
+// isPercent report if '%' followed by next token is a postfix percent
+func (p *Parser) isPercent(next token.Token, newline bool) bool {
+	_, prefix := p.prefixParseFns[next.Type]
+	_, infix := p.infixParseFns[next.Type]
+	// ( is a grouped operand, percent isn't callable
+	return !prefix || newline || (infix && next.Type != token.LPAREN)
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	open := p.curToken
 	p.nextToken()
 	exp := p.parseExpression(token.LOWEST)
 	if !p.peekToken.Is(token.RPAREN) {
-		p.errors = append(p.errors, fmt.Errorf("no closing )"))
+		p.report(p.peekToken, "no closing ), got "+describe(p.peekToken), "( is opened at "+open.Pos.String())
 		return nil
 	}
 	p.nextToken()
 	return exp
 }
 
+func (p *Parser) parseVectorLiteral() ast.Expression {
+	vec := &ast.VectorLiteral{Token: p.curToken}
+	vec.Elements = p.parseExpressionList(token.RBRACKET)
+	if p.recovering {
+		return nil
+	}
+	vec.End = p.curToken
+	return vec
+}
+
+// isJuxtaposition report if number is followed by identifier or '(' on the same line,
+// that means implicit multiplication: 2x, 3(a + b)
+func (p *Parser) isJuxtaposition(left ast.Expression) bool {
+	if p.peekNewline || !(p.peekToken.Is(token.IDENT) || p.peekToken.Is(token.LPAREN)) {
+		return false
+	}
+	switch left.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseImplicitMultiplication(left ast.Expression) ast.Expression {
+	exp := &ast.InfixExpression{
+		Token:    token.NoLiteralToken(token.MUL),
+		Left:     left,
+		Operator: "*",
+	}
+	p.nextToken()
+	exp.Right = p.parseExpression(token.IMPLICIT)
+	return exp
+}
+
+func (p *Parser) parseSelectorExpression(x ast.Expression) ast.Expression {
+	exp := &ast.SelectorExpression{Token: p.curToken, X: x}
+	if !p.peekToken.Is(token.IDENT) {
+		p.report(p.peekToken, "expected name after ., got "+describe(p.peekToken), "")
+		return nil
+	}
+	p.nextToken()
+	exp.Sel = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	return exp
+}
+
+// parsePipeExpression rewrites `x |> f(a)` into `f(x, a)`.
+// If the call has `_` placeholders among its arguments,
+// x takes their place instead: `x |> pow(_, 2)` is `pow(x, 2)`
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	tok := p.curToken
+	precedence := p.curPrecedence()
+	p.nextToken()
+	right := p.parseExpression(precedence)
+
+	call, ok := right.(*ast.CallExpression)
+	if !ok {
+		return &ast.CallExpression{Token: tok, Function: right, Arguments: []ast.Expression{left}, Piped: []int{0}}
+	}
+
+	args := make([]ast.Expression, 0, len(call.Arguments)+1)
+	var piped []int
+	for i, arg := range call.Arguments {
+		if isPlaceholder(arg) {
+			piped = append(piped, i)
+			arg = left
+		} else if nested := findPlaceholder(arg); nested != nil {
+			p.report(nested.Token, "_ is placeholder only as argument of piped call",
+				"write x |> f(_) |> g, use _n for results in REPL")
+			return nil
+		}
+		args = append(args, arg)
+	}
+	if piped == nil {
+		args = append([]ast.Expression{left}, args...)
+		piped = []int{0}
+	}
+	return &ast.CallExpression{Token: tok, Function: call.Function, Arguments: args, Piped: piped}
+}
+
+func isPlaceholder(expr ast.Expression) bool {
+	ident, ok := expr.(*ast.Identifier)
+	return ok && ident.Value == "_"
+}
+
+// findPlaceholder return _ inside of expression, it isn't replaced there: x |> f(g(_))
+func findPlaceholder(expr ast.Expression) *ast.Identifier {
+	var found *ast.Identifier
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok && found == nil && isPlaceholder(ident) {
+			found = ident
+		}
+		return found == nil
+	})
+	return found
+}
+
+// parseExpressionList parse comma separated expressions until end token
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	open := p.curToken
+	var list []ast.Expression
+	if p.peekToken.Is(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(token.LOWEST))
+	for p.peekToken.Is(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(token.LOWEST))
+	}
+
+	if !p.peekToken.Is(end) {
+		p.report(p.peekToken, fmt.Sprintf("expected , or %s, got %s", end, describe(p.peekToken)),
+			fmt.Sprintf("%s is opened at %s", open.Literal, open.Pos))
+		return nil
+	}
+	p.nextToken()
+	return list
+}
+
 func (p *Parser) nextToken() {
+	// these tokens don't get into ast, so their comments go to the next one
+	switch p.curToken.Type {
+	case token.LPAREN, token.RPAREN, token.RBRACKET, token.COMMA, token.SEMICOLON, token.ASSIGN, token.COLON, token.ARROW, token.AS:
+		p.peekToken.Trivia = token.JoinTrivia(p.curToken.Trivia, p.peekToken.Trivia)
+	}
 	p.curToken = p.peekToken
+	p.curNewline = p.peekNewline
+	if p.next != nil {
+		p.peekToken, p.peekNewline = *p.next, p.nextNewline
+		p.next = nil
+		return
+	}
 	p.peekToken = p.l.NextToken()
+	p.peekNewline = p.l.NewlineBefore()
+}
+
+// lookahead return token after peek token and if there is a line break before it
+func (p *Parser) lookahead() (token.Token, bool) {
+	if p.next == nil {
+		next := p.l.NextToken()
+		p.next, p.nextNewline = &next, p.l.NewlineBefore()
+	}
+	return *p.next, p.nextNewline
 }
 
 func (p *Parser) curPrecedence() int {
 	return p.curToken.Precedence()
 }
 
+// peekPrecedence is precedence of peek token, percent is postfix: 100 / 50% is 100 / (50%)
 func (p *Parser) peekPrecedence() int {
+	if p.peekToken.Is(token.REM) {
+		if next, newline := p.lookahead(); p.isPercent(next, newline) {
+			return token.POSTFIX
+		}
+	}
 	return p.peekToken.Precedence()
 }
 
+// report add error at tok, hint is optional.
+// Only the first error of statement is reported, parser is recovering after it.
+// Input is incomplete if the first error is caused by EOF
+func (p *Parser) report(tok token.Token, message, hint string) {
+	if p.recovering {
+		return
+	}
+	p.recovering = true
+	d := diag.Errorf(tok.Span(), "%s", message)
+	d.Hint = hint
+	p.diagnostics = append(p.diagnostics, d)
+	if tok.Is(token.EOF) && len(p.diagnostics) == 1 {
+		p.incomplete = true
+	}
+}
+
+// unexpected report token that can't start an expression
+func (p *Parser) unexpected(tok token.Token) {
+	hint := ""
+	switch tok.Type {
+	case token.ASSIGN:
+		hint = "use let to bind a name: let a = 5, == to compare"
+	case token.RPAREN:
+		hint = "there is no matching ("
+	case token.RBRACE:
+		hint = "there is no matching {"
+	case token.RBRACKET:
+		hint = "there is no matching ["
+	case token.ILLEGAL:
+		if strings.HasPrefix(tok.Literal, `"`) {
+			p.report(tok, "unterminated string", "close it with \" on the same line")
+			return
+		}
+		p.report(tok, "illegal token "+describe(tok), "")
+		return
+	}
+	p.report(tok, "unexpected "+describe(tok), hint)
+}
+
+// describe token for error message
+func describe(tok token.Token) string {
+	switch tok.Type {
+	case token.EOF:
+		return "end of input"
+	case token.PRAGMA:
+		return "#" + tok.Literal
+	}
+	return strconv.Quote(tok.Literal)
+}
+
+// Incomplete report if parsing failed only because input ended too early:
+// unclosed braces or parenthesis, operator without right operand and so on.
+// REPL use it to ask for more input
+func (p *Parser) Incomplete() bool {
+	return p.incomplete
+}
+
 func (p *Parser) HasErrors() bool {
-	return len(p.errors) != 0
+	return diag.HasErrors(p.diagnostics)
 }
 
+// PrintErrors print diagnostics with hints
 func (p *Parser) PrintErrors(out io.Writer) {
-	for _, err := range p.Errors() {
-		fmt.Fprintln(out, err)
+	for _, d := range p.diagnostics {
+		fmt.Fprintln(out, d)
 	}
 }
 
+// Diagnostics return errors of lexer and parser sorted by position
+func (p *Parser) Diagnostics() []diag.Diagnostic {
+	return p.diagnostics
+}
+
+// Errors return diagnostics as errors
 func (p *Parser) Errors() []error {
-	return p.errors
+	errs := make([]error, len(p.diagnostics))
+	for i, d := range p.diagnostics {
+		errs[i] = d
+	}
+	return errs
 }
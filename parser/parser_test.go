@@ -65,7 +65,9 @@ func TestIdentifier(t *testing.T) {
 			if !ok {
 				t.Fatalf("stmt exp not a *ast.Identifier: %T\n", stmt.Expr)
 			}
-			if ident.Token != tt.token {
+			tok := ident.Token
+			tok.Pos = token.Pos{}
+			if tok != tt.token {
 				t.Errorf("mismatch tokens expected: %s got: %s\n", tt.token, ident.Token)
 			}
 			if ident.Value != tt.value {
@@ -399,3 +401,677 @@ func TestBlockStatement(t *testing.T) {
 		t.Errorf("expected: %s got: %s", expected, stringRepr)
 	}
 }
+
+func TestCallExpressions(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "no arguments",
+			input:  "foo()",
+			output: "foo()",
+		},
+		{
+			desc:   "few arguments",
+			input:  "pow(a, 2 + 3)",
+			output: "pow(a, (2 + 3))",
+		},
+		{
+			desc:   "call binds tighter than unary",
+			input:  "-abs(a) * 2",
+			output: "((-abs(a)) * 2)",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestVectorLiterals(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		input   string
+		output  string
+		wantErr bool
+	}{
+		{
+			desc:   "empty",
+			input:  "[]",
+			output: "[]",
+		},
+		{
+			desc:   "elements",
+			input:  "[1, -x, 2 + 3]",
+			output: "[1, (-x), (2 + 3)]",
+		},
+		{
+			desc:   "matrix",
+			input:  "[[1, 2], [3, 4]]",
+			output: "[[1, 2], [3, 4]]",
+		},
+		{
+			desc:   "operand",
+			input:  "2 * [1, 2] + v",
+			output: "((2 * [1, 2]) + v)",
+		},
+		{
+			desc:    "unclosed",
+			input:   "[1, 2",
+			wantErr: true,
+		},
+		{
+			desc:    "missing comma",
+			input:   "[1 2]",
+			wantErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			if tt.wantErr {
+				if !p.HasErrors() {
+					t.Errorf("expected error, got %s", program)
+				}
+				return
+			}
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		input   string
+		output  string
+		wantErr bool
+	}{
+		{
+			desc:   "let",
+			input:  "let rate: float = 0.05",
+			output: "let rate: float = 0.05",
+		},
+		{
+			desc:   "function",
+			input:  "fn area(r: float) -> float { 3 * r * r }",
+			output: "fn area(r: float) -> float { ((3 * r) * r); }",
+		},
+		{
+			desc:   "no annotations",
+			input:  "fn f(a, b) {\n  a + b\n}",
+			output: "fn f(a, b) { (a + b); }",
+		},
+		{
+			desc:   "no parameters",
+			input:  "fn one() -> int { 1 }; one()",
+			output: "fn one() -> int { 1; }one()",
+		},
+		{
+			desc:    "unknown type",
+			input:   "let a: string = 1",
+			wantErr: true,
+		},
+		{
+			desc:    "missing type",
+			input:   "fn f(a:) { a }",
+			wantErr: true,
+		},
+		{
+			desc:    "missing body",
+			input:   "fn f(a) -> int",
+			wantErr: true,
+		},
+		{
+			desc:    "missing comma",
+			input:   "fn f(a b) { a }",
+			wantErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			if tt.wantErr {
+				if !p.HasErrors() {
+					t.Errorf("expected error, got %s", program)
+				}
+				return
+			}
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestImports(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		input   string
+		output  string
+		wantErr bool
+	}{
+		{
+			desc:   "import",
+			input:  "import \"stats.fe\" as s; s.mean(v)",
+			output: "import \"stats.fe\" as ss.mean(v)",
+		},
+		{
+			desc:   "selector binds tighter than operators",
+			input:  "-s.x! + 2s.y",
+			output: "((-(s.x!)) + (2 * s.y))",
+		},
+		{
+			desc:   "selector of call",
+			input:  "f(a).x",
+			output: "f(a).x",
+		},
+		{
+			desc:    "import in block",
+			input:   "{ import \"stats.fe\" as s }",
+			wantErr: true,
+		},
+		{
+			desc:    "missing alias",
+			input:   "import \"stats.fe\"",
+			wantErr: true,
+		},
+		{
+			desc:    "path is not a string",
+			input:   "import stats as s",
+			wantErr: true,
+		},
+		{
+			desc:    "unterminated path",
+			input:   "import \"stats.fe as s",
+			wantErr: true,
+		},
+		{
+			desc:    "missing name after dot",
+			input:   "s.1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			if tt.wantErr {
+				if !p.HasErrors() {
+					t.Errorf("expected error, got %s", program)
+				}
+				return
+			}
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestPipeExpressions(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		input   string
+		output  string
+		wantErr bool
+	}{
+		{
+			desc:   "identifier",
+			input:  "x |> abs",
+			output: "abs(x)",
+		},
+		{
+			desc:   "chain",
+			input:  "x |> abs |> sqrt",
+			output: "sqrt(abs(x))",
+		},
+		{
+			desc:   "left value is first argument",
+			input:  "x |> pow(2)",
+			output: "pow(x, 2)",
+		},
+		{
+			desc:   "placeholder",
+			input:  "x |> pow(2, _)",
+			output: "pow(2, x)",
+		},
+		{
+			desc:   "lowest precedence",
+			input:  "a or b + 1 |> f",
+			output: "f((a or (b + 1)))",
+		},
+		{
+			desc:   "placeholder of nested pipe",
+			input:  "x |> f(y |> g(_))",
+			output: "f(x, g(y))",
+		},
+		{
+			desc:    "placeholder in nested call",
+			input:   "x |> f(g(_))",
+			wantErr: true,
+		},
+		{
+			desc:    "placeholder in operand",
+			input:   "x |> pow(2, _ + 1)",
+			wantErr: true,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			if tt.wantErr {
+				if !p.HasErrors() {
+					t.Errorf("expected error, got %s", program)
+				}
+				return
+			}
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestImplicitMultiplication(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "number and identifier",
+			input:  "2x",
+			output: "(2 * x)",
+		},
+		{
+			desc:   "number and parenthesis",
+			input:  "3(a + b)",
+			output: "(3 * (a + b))",
+		},
+		{
+			desc:   "binds tighter than division",
+			input:  "1 / 2x",
+			output: "(1 / (2 * x))",
+		},
+		{
+			desc:   "binds tighter than unary",
+			input:  "-2x",
+			output: "(-(2 * x))",
+		},
+		{
+			desc:   "number and call",
+			input:  "2sin(x)",
+			output: "(2 * sin(x))",
+		},
+		{
+			desc:   "scientific notation",
+			input:  "2e5",
+			output: "2e5",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestNoImplicitMultiplicationAcrossLines(t *testing.T) {
+	p := parser.New(lexer.New("2\n(x + 1)"))
+	program := p.Parse()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements, expected: 2 got: %d\n", len(program.Statements))
+	}
+}
+
+func TestPowerExpressions(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "right associativity",
+			input:  "2^3^2",
+			output: "(2 ^ (3 ^ 2))",
+		},
+		{
+			desc:   "binds tighter than unary minus",
+			input:  "-x^2",
+			output: "(-(x ^ 2))",
+		},
+		{
+			desc:   "negative exponent",
+			input:  "x^-1",
+			output: "(x ^ (-1))",
+		},
+		{
+			desc:   "binds tighter than implicit multiplication",
+			input:  "2x^2",
+			output: "(2 * (x ^ 2))",
+		},
+		{
+			desc:   "implicit multiplication in exponent",
+			input:  "e^2x + 1",
+			output: "((e ^ (2 * x)) + 1)",
+		},
+		{
+			desc:   "factorial",
+			input:  "x!^2^n!",
+			output: "((x!) ^ (2 ^ (n!)))",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestPostfixExpressions(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "factorial",
+			input:  "5!",
+			output: "(5!)",
+		},
+		{
+			desc:   "factorial binds tighter than unary minus",
+			input:  "-3!",
+			output: "(-(3!))",
+		},
+		{
+			desc:   "factorial binds tighter than implicit multiplication",
+			input:  "2x!",
+			output: "(2 * (x!))",
+		},
+		{
+			desc:   "factorial and not equal",
+			input:  "5! == 120",
+			output: "((5!) == 120)",
+		},
+		{
+			desc:   "percent",
+			input:  "50% + 1",
+			output: "((50%) + 1)",
+		},
+		{
+			desc:   "percent at the end",
+			input:  "x * 15%",
+			output: "(x * (15%))",
+		},
+		{
+			desc:   "percent binds tighter than division",
+			input:  "100 / 50%",
+			output: "(100 / (50%))",
+		},
+		{
+			desc:   "percent before newline",
+			input:  "100 / 50%\n1",
+			output: "(100 / (50%))1",
+		},
+		{
+			desc:   "remainder",
+			input:  "7 % (2 + 1)",
+			output: "(7 % (2 + 1))",
+		},
+		{
+			desc:   "percent before binary minus",
+			input:  "50% - 10",
+			output: "((50%) - 10)",
+		},
+		{
+			desc:   "remainder by negative",
+			input:  "7 % (-2)",
+			output: "(7 % (-2))",
+		},
+		{
+			desc:   "percent factorial",
+			input:  "50%!",
+			output: "((50%)!)",
+		},
+		{
+			desc:   "remainder of operand",
+			input:  "7 % 2x",
+			output: "(7 % (2 * x))",
+		},
+		{
+			desc:   "factorial equal without spaces",
+			input:  "3!==6",
+			output: "((3!) == 6)",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			checkParserErrors(t, p)
+			if s := program.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestComparisonChain(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "range",
+			input:  "0 < x <= 10",
+			output: "(0 < x <= 10)",
+		},
+		{
+			desc:   "equality",
+			input:  "a == b != c",
+			output: "(a == b != c)",
+		},
+		{
+			desc:   "mixed with arithmetic",
+			input:  "0 <= x + 1 < 2y",
+			output: "(0 <= (x + 1) < (2 * y))",
+		},
+		{
+			desc:   "inside logic",
+			input:  "0 < x < 1 or y > 1",
+			output: "((0 < x < 1) or (y > 1))",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			checkParserErrors(t, p)
+			stmt := program.Statements[0].(*ast.ExpressionStatement)
+			if s := stmt.String(); s != tt.output {
+				t.Errorf("expected: %s got: %s\n", tt.output, s)
+			}
+		})
+	}
+}
+
+func TestPragmaStatement(t *testing.T) {
+	p := parser.New(lexer.New("#strict\n1 + 1"))
+	program := p.Parse()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements, expected: 2 got: %d\n", len(program.Statements))
+	}
+	pragma, ok := program.Statements[0].(*ast.PragmaStatement)
+	if !ok {
+		t.Fatalf("stmt not a *ast.PragmaStatement: %T\n", program.Statements[0])
+	}
+	if pragma.Name != "strict" {
+		t.Errorf("wrong pragma expected: strict got: %s\n", pragma.Name)
+	}
+
+	// unknown pragma is just a comment
+	p = parser.New(lexer.New("#unknown"))
+	program = p.Parse()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 0 {
+		t.Errorf("comment parsed as statement: %s\n", program)
+	}
+}
+
+func TestIncompleteInput(t *testing.T) {
+	testCases := []struct {
+		input      string
+		incomplete bool
+	}{
+		{input: "1 + 1", incomplete: false},
+		{input: "1 +", incomplete: true},
+		{input: "(1 + 2", incomplete: true},
+		{input: "pow(1,", incomplete: true},
+		{input: "{ let a = 1", incomplete: true},
+		{input: "let a =", incomplete: true},
+		{input: "let", incomplete: true},
+		{input: "1 + )", incomplete: false},
+		{input: "let 1 = (2", incomplete: false},
+		{input: "1 /* comment", incomplete: true},
+		{input: "1 + /* comment", incomplete: true},
+		{input: "1 + ) /* comment", incomplete: false},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.input, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			p.Parse()
+			if p.Incomplete() != tt.incomplete {
+				t.Errorf("expected incomplete: %t got: %t", tt.incomplete, p.Incomplete())
+			}
+		})
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		input       string
+		diagnostics []string
+		statements  []string
+	}{
+		{
+			desc:        "error on each line",
+			input:       "1 +\nlet = 2\nlet a = 3\n)",
+			diagnostics: []string{"2:1: unexpected \"let\"", "2:5: let: expected name, got \"=\"", "4:1: unexpected \")\""},
+			statements:  []string{"let a = 3"},
+		},
+		{
+			desc:        "semicolon",
+			input:       "1 + ; 2; * 3; 4",
+			diagnostics: []string{"1:5: unexpected \";\"", "1:10: unexpected \"*\""},
+			statements:  []string{"2", "4"},
+		},
+		{
+			desc:        "inside block",
+			input:       "{ 1 + }\n{ let a = 1; (2 }\n5",
+			diagnostics: []string{"1:7: unexpected \"}\"", "2:17: no closing ), got \"}\""},
+			statements:  []string{"5"},
+		},
+		{
+			desc:        "nested blocks",
+			input:       "{ { * } 1 }\n2",
+			diagnostics: []string{"1:5: unexpected \"*\""},
+			statements:  []string{"2"},
+		},
+		{
+			desc:        "only first error of statement",
+			input:       "(1 + (2 * ) ) )",
+			diagnostics: []string{"1:11: unexpected \")\""},
+		},
+		{
+			desc:        "unclosed block",
+			input:       "{ 1\n2",
+			diagnostics: []string{"2:2: no closing }"},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := parser.New(lexer.New(tt.input))
+			program := p.Parse()
+			diagnostics := p.Diagnostics()
+			if len(diagnostics) != len(tt.diagnostics) {
+				t.Fatalf("expected diagnostics: %q got: %v\n", tt.diagnostics, diagnostics)
+			}
+			for i, d := range diagnostics {
+				if d.Error() != tt.diagnostics[i] {
+					t.Errorf("[%d] expected: %q got: %q\n", i, tt.diagnostics[i], d.Error())
+				}
+			}
+			if len(program.Statements) != len(tt.statements) {
+				t.Fatalf("expected statements: %q got: %v\n", tt.statements, program.Statements)
+			}
+			for i, stmt := range program.Statements {
+				if stmt.String() != tt.statements[i] {
+					t.Errorf("[%d] expected: %q got: %q\n", i, tt.statements[i], stmt.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnosticHint(t *testing.T) {
+	p := parser.New(lexer.New("a = 5"))
+	p.Parse()
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic got: %v\n", diagnostics)
+	}
+	d := diagnostics[0]
+	if d.Span.Start != (token.Pos{Line: 1, Col: 3}) || d.Span.End != (token.Pos{Line: 1, Col: 4}) {
+		t.Errorf("wrong span: %v\n", d.Span)
+	}
+	if d.Hint == "" {
+		t.Errorf("no hint for %s\n", d)
+	}
+}
+
+// FuzzParse check that parser terminates on any input
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"1 + 2", "{", "}", "(", ")", "{ ( }", "let", "let a", "let a =", "f(1,", "x |> ", "1 < < 2",
+		"{{{ 1 }", "/* ", "#strict {", ";;;", "1 % % %", "!!!", "2x(", "a ! (",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		p := parser.New(lexer.New(source))
+		p.Parse()
+	})
+}
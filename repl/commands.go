@@ -0,0 +1,271 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/token"
+	"github.com/Richtermnd/ferret/types"
+)
+
+// command is a REPL meta-command like :env
+type command struct {
+	name string
+	args string
+	help string
+	run  func(s *session, arg string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "env", help: "show bindings", run: cmdEnv},
+		{name: "type", args: "expr", help: "show type of expression", run: cmdType},
+		{name: "ast", args: "expr", help: "show syntax tree of expression", run: cmdAst},
+		{name: "tokens", args: "expr", help: "show tokens of expression", run: cmdTokens},
+		{name: "time", args: "expr", help: "evaluate expression and show how long it took", run: cmdTime},
+		{name: "load", args: "file.fe", help: "evaluate file in current session", run: cmdLoad},
+		{name: "save", args: "file.fe", help: "save everything evaluated in session to file", run: cmdSave},
+		{name: "hist", help: "show previous results (_1, _2, ..., _ is the last one)", run: cmdHist},
+		{name: "reset", help: "forget all bindings", run: cmdReset},
+		{name: "help", help: "show this help", run: cmdHelp},
+	}
+}
+
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), ":")
+}
+
+func (s *session) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line)[1:], " ")
+	arg = strings.TrimSpace(arg)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if cmd.args != "" && arg == "" {
+			fmt.Fprintf(s.out, "usage: :%s %s\n", cmd.name, cmd.args)
+			return
+		}
+		cmd.run(s, arg)
+		return
+	}
+	fmt.Fprintf(s.out, "unknown command :%s, try :help\n", name)
+}
+
+func cmdEnv(s *session, _ string) {
+	fmt.Fprint(s.out, s.env.String())
+}
+
+func cmdType(s *session, arg string) {
+	program, ok := s.parse(arg)
+	if !ok {
+		return
+	}
+	// inferred without evaluation, bindings of session are known by their values
+	globals := make(map[string]types.Type)
+	for _, name := range s.env.Names() {
+		obj, _ := s.env.Get(name)
+		globals[name] = types.Of(obj)
+	}
+	info, diagnostics := types.Check(program, types.Options{Strict: s.env.Strict(), Globals: globals})
+	for _, d := range diagnostics {
+		fmt.Fprintln(s.out, d)
+	}
+	if diag.HasErrors(diagnostics) || len(program.Statements) == 0 {
+		return
+	}
+	if stmt, ok := program.Statements[len(program.Statements)-1].(*ast.ExpressionStatement); ok {
+		fmt.Fprintln(s.out, info.TypeOf(stmt.Expr))
+	}
+}
+
+func cmdAst(s *session, arg string) {
+	if program, ok := s.parse(arg); ok {
+		fmt.Fprint(s.out, ast.Dump(program))
+	}
+}
+
+func cmdTokens(s *session, arg string) {
+	l := lexer.New(arg)
+	for tok := l.NextToken(); !tok.Is(token.EOF); tok = l.NextToken() {
+		fmt.Fprintln(s.out, tok)
+	}
+}
+
+func cmdTime(s *session, arg string) {
+	program, ok := s.parse(arg)
+	if !ok {
+		return
+	}
+	start := time.Now()
+	s.run(arg, "", program)
+	fmt.Fprintf(s.out, "time: %s\n", time.Since(start))
+}
+
+func cmdLoad(s *session, arg string) {
+	source, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintf(s.out, "failed to read %s: %v\n", arg, err)
+		return
+	}
+	program, ok := s.parse(string(source))
+	if !ok {
+		return
+	}
+	s.run(string(source), arg, program)
+}
+
+func cmdSave(s *session, arg string) {
+	source, err := s.save()
+	if err != nil {
+		fmt.Fprintf(s.out, "failed to save %s: %v\n", arg, err)
+		return
+	}
+	if err := os.WriteFile(arg, []byte(source), 0o644); err != nil {
+		fmt.Fprintf(s.out, "failed to save %s: %v\n", arg, err)
+	}
+}
+
+func cmdHist(s *session, _ string) {
+	for i, res := range s.results {
+		fmt.Fprintf(s.out, "%s = %s\n", resultName(i+1), res.Inspect())
+	}
+}
+
+func cmdReset(s *session, _ string) {
+	s.reset()
+}
+
+func cmdHelp(s *session, _ string) {
+	for _, cmd := range commands {
+		usage := ":" + cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(s.out, "  %-16s %s\n", usage, cmd.help)
+	}
+}
+
+// entry is a source evaluated in session,
+// result is number of its result in _1, _2, ... or 0 if it has no result
+type entry struct {
+	source  string
+	program *ast.Program
+	result  int
+}
+
+// edit replace n bytes at offset with text
+type edit struct {
+	offset, n int
+	text      string
+}
+
+// save return history as source of file. Results are not bound there,
+// so _ is replaced with _1, _2, ... it refers to and entries
+// with referenced results bind them with let
+func (s *session) save() (string, error) {
+	edits := make([][]edit, len(s.history))
+	used := make(map[int]bool)
+	last := 0
+	for i, e := range s.history {
+		for _, ident := range identifiers(e.program) {
+			n := resultIndex(ident.Value, last)
+			if n == 0 {
+				continue
+			}
+			used[n] = true
+			if ident.Value == "_" {
+				edits[i] = append(edits[i], edit{offset: offset(e.source, ident.Token.Pos), n: 1, text: resultName(n)})
+			}
+		}
+		if e.result > 0 {
+			last = e.result
+		}
+	}
+
+	var sb strings.Builder
+	for i, e := range s.history {
+		if used[e.result] {
+			stmt, ok := e.program.Statements[len(e.program.Statements)-1].(*ast.ExpressionStatement)
+			if !ok {
+				return "", fmt.Errorf("%s is used later, but it's not a value of expression: %s", resultName(e.result), e.source)
+			}
+			start := ast.Span(stmt).Start
+			edits[i] = append(edits[i], edit{offset: offset(e.source, start), text: "let " + resultName(e.result) + " = "})
+		}
+		sb.WriteString(apply(e.source, edits[i]))
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
+
+// resultIndex return number of result name refers to when last is the latest result, 0 if it's not a result
+func resultIndex(name string, last int) int {
+	if name == "_" {
+		return last
+	}
+	digits, ok := strings.CutPrefix(name, "_")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 || n > last || resultName(n) != name {
+		return 0
+	}
+	return n
+}
+
+// identifiers return references in program, names being defined are skipped
+func identifiers(program *ast.Program) []*ast.Identifier {
+	defined := make(map[*ast.Identifier]bool)
+	var idents []*ast.Identifier
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			defined[n.Name] = true
+		case *ast.FunctionStatement:
+			defined[n.Name] = true
+			for _, param := range n.Parameters {
+				defined[param.Name] = true
+			}
+		case *ast.ImportStatement:
+			defined[n.Alias] = true
+		case *ast.Identifier:
+			if !defined[n] {
+				idents = append(idents, n)
+			}
+		}
+		return true
+	})
+	return idents
+}
+
+// offset of position in source, columns are in bytes
+func offset(source string, pos token.Pos) int {
+	off := 0
+	for range pos.Line - 1 {
+		i := strings.IndexByte(source[off:], '\n')
+		if i < 0 {
+			return len(source)
+		}
+		off += i + 1
+	}
+	return min(off+pos.Col-1, len(source))
+}
+
+func apply(source string, edits []edit) string {
+	slices.SortFunc(edits, func(a, b edit) int { return b.offset - a.offset })
+	for _, e := range edits {
+		source = source[:e.offset] + e.text + source[e.offset+e.n:]
+	}
+	return source
+}
@@ -0,0 +1,179 @@
+package repl_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/repl"
+)
+
+func runSession(t *testing.T, input string) string {
+	t.Helper()
+	var out strings.Builder
+	repl.Start(strings.NewReader(input), &out, object.NewEnv())
+	output := strings.ReplaceAll(out.String(), repl.PROMPT, "")
+	return strings.ReplaceAll(output, repl.CONTINUE_PROMPT, "")
+}
+
+func TestCommands(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "env",
+			input:  "let b = 2\nlet a = 1.5\n:env\n",
+			output: "{\n  a: FLOAT = 1.500000\n  b: INTEGER = 2\n}\n",
+		},
+		{
+			desc:   "type",
+			input:  ":type 1 + 1.5\n",
+			output: "float\n",
+		},
+		{
+			desc:   "type of binding",
+			input:  "let v = [1, 2.5]\n:type 2v\n",
+			output: "[2]number\n",
+		},
+		{
+			desc:   "type doesn't evaluate",
+			input:  ":type 1 / 0\n",
+			output: "int\n",
+		},
+		{
+			desc:   "type error",
+			input:  ":type true * [1, 2]\n",
+			output: "1:1: invalid operation: bool * [2]int\n",
+		},
+		{
+			desc:   "type doesn't bind",
+			input:  ":type let a = 1\na\n",
+			output: "[ERROR] not found: a\n",
+		},
+		{
+			desc:   "ast",
+			input:  ":ast -a\n",
+			output: "Program\n  Statements: [\n    0: ExpressionStatement\n      Expr: PrefixExpression\n        Operator: \"-\"\n        Right: Identifier\n          Value: \"a\"\n  ]\n",
+		},
+		{
+			desc:   "tokens",
+			input:  ":tokens 1 + a\n",
+			output: "[int] 1\n[+] +\n[ident] a\n",
+		},
+		{
+			desc:   "reset",
+			input:  "let a = 1\n:reset\na\n",
+			output: "[ERROR] not found: a\n",
+		},
+		{
+			desc:   "usage",
+			input:  ":type\n",
+			output: "usage: :type expr\n",
+		},
+		{
+			desc:   "unknown",
+			input:  ":foo\n",
+			output: "unknown command :foo, try :help\n",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if out := runSession(t, tt.input); out != tt.output {
+				t.Errorf("expected: %q got: %q", tt.output, out)
+			}
+		})
+	}
+}
+
+func TestTimeCommand(t *testing.T) {
+	out := runSession(t, ":time let a = 2\na\n")
+	if !strings.HasPrefix(out, "time: ") || !strings.HasSuffix(out, "\n2\n") {
+		t.Errorf("wrong output: %q", out)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.fe")
+	out := runSession(t, "let a = 2\nb\n{\nlet c = 1\n}\n:save "+path+"\n")
+	if out != "[ERROR] not found: b\n" {
+		t.Errorf("wrong output: %q", out)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "let a = 2\n{\nlet c = 1\n}\n"; string(saved) != expected {
+		t.Errorf("wrong session file expected: %q got: %q", expected, saved)
+	}
+
+	out = runSession(t, ":load "+path+"\na * 3\n")
+	if out != "6\n" {
+		t.Errorf("wrong output after load: %q", out)
+	}
+}
+
+func TestSaveResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.fe")
+	runSession(t, "2 + 3\n_ * 2\n7\nlet a = _1 + _\n:save "+path+"\n")
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "let _1 = 2 + 3\n_1 * 2\nlet _3 = 7\nlet a = _1 + _3\n"; string(saved) != expected {
+		t.Errorf("wrong session file expected: %q got: %q", expected, saved)
+	}
+	if out := runSession(t, ":load "+path+"\na\n"); out != "12\n" {
+		t.Errorf("wrong output after load: %q", out)
+	}
+
+	out := runSession(t, "{ 1 }\n_ + 1\n:save "+path+"\n")
+	if !strings.HasSuffix(out, "failed to save "+path+": _1 is used later, but it's not a value of expression: { 1 }\n") {
+		t.Errorf("expected failed save got: %q", out)
+	}
+}
+
+func TestResultHistory(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "last result",
+			input:  "2 + 3\n_ * 2\n_ + 1\n",
+			output: "5\n10\n11\n",
+		},
+		{
+			desc:   "numbered results",
+			input:  "2\n3\n_1 + _2\n",
+			output: "2\n3\n5\n",
+		},
+		{
+			desc:   "errors and lets are skipped",
+			input:  "2\nfoo\nlet a = 5\n_\n_2\n",
+			output: "2\n[ERROR] not found: foo\n2\n2\n",
+		},
+		{
+			desc:   "hist",
+			input:  "2\n1.5\n:hist\n",
+			output: "2\n1.500000\n_1 = 2\n_2 = 1.500000\n",
+		},
+		{
+			desc:   "reset",
+			input:  "2\n:reset\n:hist\n_\n",
+			output: "2\n[ERROR] not found: _\n",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			if out := runSession(t, tt.input); out != tt.output {
+				t.Errorf("expected: %q got: %q", tt.output, out)
+			}
+		})
+	}
+}
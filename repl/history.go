@@ -0,0 +1,110 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	HISTORY_FILE = ".ferret_history"
+	HISTORY_SIZE = 1000
+)
+
+// HistoryPath return path of history file in user home directory
+func HistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+// History is a list of entered lines, the newest is the last.
+// If path isn't empty every added line is appended to the file
+type History struct {
+	lines []string
+	path  string
+}
+
+// NewHistory create history and load lines from file at path (if it exists)
+func NewHistory(path string) *History {
+	h := &History{path: path}
+	if path == "" {
+		return h
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		h.lines = append(h.lines, s.Text())
+	}
+	if len(h.lines) > HISTORY_SIZE {
+		h.lines = h.lines[len(h.lines)-HISTORY_SIZE:]
+		h.rewrite()
+	}
+	return h
+}
+
+func (h *History) Len() int {
+	return len(h.lines)
+}
+
+// At return i-th line, 0 is the oldest
+func (h *History) At(i int) string {
+	return h.lines[i]
+}
+
+// Add append line to history, skip empty lines and repeats of the last one
+func (h *History) Add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > HISTORY_SIZE {
+		h.lines = h.lines[1:]
+	}
+	h.append(line)
+}
+
+// Search find the newest line before index from containing query, return -1 if not found
+func (h *History) Search(query string, from int) int {
+	for i := min(from, len(h.lines)) - 1; i >= 0; i-- {
+		if strings.Contains(h.lines[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// history file is best effort, REPL works without it
+
+func (h *History) append(line string) {
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
+
+func (h *History) rewrite() {
+	f, err := os.OpenFile(h.path, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	for _, line := range h.lines {
+		f.WriteString(line + "\n")
+	}
+}
@@ -0,0 +1,378 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupt returned by ReadLine on Ctrl-C
+var ErrInterrupt = errors.New("interrupted")
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// keys from escape sequences, out of unicode range
+const (
+	keyUp = unicode.MaxRune + 1 + iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// LineEditor is a minimal readline: it expects terminal in raw mode,
+// read keys from in and draw the line to out with ANSI escape codes.
+//
+// Keys: arrows, Home/End, Delete, Ctrl-A/E/B/F/K/U/W/L,
+// Up/Down (Ctrl-P/N) walk through history, Ctrl-R is reverse search,
+// Tab complete the word before cursor
+type LineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history *History
+
+	// completer return words for Tab completion, may be nil
+	completer func() []string
+
+	prompt string
+	buf    []rune
+	pos    int
+
+	// histPos is a position in history while walking through it,
+	// history.Len() means the line that is being edited (it is saved in edited)
+	histPos int
+	edited  []rune
+
+	// pending is a key that finished reverse search and must be handled as usual
+	pending rune
+}
+
+func NewLineEditor(in io.Reader, out io.Writer, history *History, completer func() []string) *LineEditor {
+	if history == nil {
+		history = NewHistory("")
+	}
+	return &LineEditor{
+		in:        bufio.NewReader(in),
+		out:       out,
+		history:   history,
+		completer: completer,
+	}
+}
+
+// ReadLine read one line, return io.EOF on Ctrl-D in empty line
+// and ErrInterrupt on Ctrl-C
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	e.prompt = prompt
+	e.buf = e.buf[:0]
+	e.pos = 0
+	e.histPos = e.history.Len()
+	e.edited = nil
+	e.refresh()
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+		switch key {
+		case keyEnter, keyCtrlJ:
+			return e.accept(), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupt
+		case keyCtrlD:
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.delete(e.pos)
+		case keyBackspace, keyCtrlH:
+			if e.pos > 0 {
+				e.pos--
+				e.delete(e.pos)
+			}
+		case keyDelete:
+			e.delete(e.pos)
+		case keyLeft, keyCtrlB:
+			e.pos = max(e.pos-1, 0)
+		case keyRight, keyCtrlF:
+			e.pos = min(e.pos+1, len(e.buf))
+		case keyHome, keyCtrlA:
+			e.pos = 0
+		case keyEnd, keyCtrlE:
+			e.pos = len(e.buf)
+		case keyCtrlK:
+			e.buf = e.buf[:e.pos]
+		case keyCtrlU:
+			e.buf = slices.Delete(e.buf, 0, e.pos)
+			e.pos = 0
+		case keyCtrlW:
+			for e.pos > 0 && e.buf[e.pos-1] == ' ' {
+				e.pos--
+				e.delete(e.pos)
+			}
+			start := e.wordStart()
+			e.buf = slices.Delete(e.buf, start, e.pos)
+			e.pos = start
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyUp, keyCtrlP:
+			e.walkHistory(-1)
+		case keyDown, keyCtrlN:
+			e.walkHistory(1)
+		case keyTab:
+			e.complete()
+		case keyCtrlR:
+			line, accepted, err := e.reverseSearch()
+			if err != nil {
+				return "", err
+			}
+			if accepted {
+				return line, nil
+			}
+		default:
+			if unicode.IsPrint(key) {
+				e.buf = slices.Insert(e.buf, e.pos, key)
+				e.pos++
+			}
+		}
+		e.refresh()
+	}
+}
+
+func (e *LineEditor) accept() string {
+	fmt.Fprint(e.out, "\r\n")
+	line := string(e.buf)
+	e.history.Add(line)
+	return line
+}
+
+func (e *LineEditor) delete(i int) {
+	if i < len(e.buf) {
+		e.buf = slices.Delete(e.buf, i, i+1)
+	}
+}
+
+// refresh redraw prompt and line, then put cursor in place
+func (e *LineEditor) refresh() {
+	e.draw(e.prompt)
+}
+
+func (e *LineEditor) draw(prompt string) {
+	fmt.Fprintf(e.out, "\r\x1b[K%s%s", prompt, string(e.buf))
+	if back := len(e.buf) - e.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+func (e *LineEditor) readKey() (rune, error) {
+	if e.pending != 0 {
+		key := e.pending
+		e.pending = 0
+		return key, nil
+	}
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r != keyEscape {
+		return r, nil
+	}
+
+	// ESC [ <params> <final> or ESC O <final>
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+	var params strings.Builder
+	for {
+		r, _, err = e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if '0' <= r && r <= '9' || r == ';' {
+			params.WriteRune(r)
+			continue
+		}
+		break
+	}
+	switch r {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	case '~':
+		switch params.String() {
+		case "1", "7":
+			return keyHome, nil
+		case "4", "8":
+			return keyEnd, nil
+		case "3":
+			return keyDelete, nil
+		}
+	}
+	return keyUnknown, nil
+}
+
+// walkHistory move through history by delta (-1 is older)
+func (e *LineEditor) walkHistory(delta int) {
+	next := e.histPos + delta
+	if next < 0 || next > e.history.Len() {
+		return
+	}
+	if e.histPos == e.history.Len() {
+		e.edited = slices.Clone(e.buf)
+	}
+	e.histPos = next
+	if next == e.history.Len() {
+		e.buf = slices.Clone(e.edited)
+	} else {
+		e.buf = []rune(e.history.At(next))
+	}
+	e.pos = len(e.buf)
+}
+
+// reverseSearch is an incremental search (Ctrl-R).
+// Enter accept found line, Ctrl-G or Ctrl-C cancel search,
+// any other key leave found line in buffer and is handled as usual
+func (e *LineEditor) reverseSearch() (string, bool, error) {
+	original := slices.Clone(e.buf)
+	var query []rune
+	match := e.history.Len()
+
+	for {
+		e.draw(fmt.Sprintf("(reverse-i-search)`%s': ", string(query)))
+		key, err := e.readKey()
+		if err != nil {
+			return "", false, err
+		}
+		switch key {
+		case keyEnter, keyCtrlJ:
+			return e.accept(), true, nil
+		case keyCtrlG, keyCtrlC:
+			e.buf = original
+			e.pos = len(e.buf)
+			return "", false, nil
+		case keyCtrlR:
+			if i := e.history.Search(string(query), match); i >= 0 {
+				match = i
+			}
+		case keyBackspace, keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			match = e.history.Len()
+			if i := e.history.Search(string(query), match); i >= 0 && len(query) > 0 {
+				match = i
+			}
+		default:
+			if !unicode.IsPrint(key) {
+				e.pending = key
+				return "", false, nil
+			}
+			query = append(query, key)
+			// current match can still match longer query
+			if i := e.history.Search(string(query), match+1); i >= 0 {
+				match = i
+			}
+		}
+		if match < e.history.Len() {
+			e.buf = []rune(e.history.At(match))
+			e.pos = len(e.buf)
+		}
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func (e *LineEditor) wordStart() int {
+	start := e.pos
+	for start > 0 && isWordRune(e.buf[start-1]) {
+		start--
+	}
+	return start
+}
+
+// complete word before cursor: unique candidate is inserted,
+// several candidates are completed to common prefix or listed if there is no common part
+func (e *LineEditor) complete() {
+	if e.completer == nil {
+		return
+	}
+	start := e.wordStart()
+	prefix := string(e.buf[start:e.pos])
+	if prefix == "" {
+		return
+	}
+
+	var candidates []string
+	for _, word := range e.completer() {
+		if strings.HasPrefix(word, prefix) {
+			candidates = append(candidates, word)
+		}
+	}
+	slices.Sort(candidates)
+	candidates = slices.Compact(candidates)
+
+	if len(candidates) == 0 {
+		fmt.Fprint(e.out, "\a")
+		return
+	}
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(candidates) == 1 {
+		common += " "
+	}
+	if common == prefix {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+		return
+	}
+	insert := []rune(common[len(prefix):])
+	e.buf = slices.Insert(e.buf, e.pos, insert...)
+	e.pos += len(insert)
+}
@@ -0,0 +1,109 @@
+package repl_test
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Richtermnd/ferret/repl"
+)
+
+const (
+	up        = "\x1b[A"
+	down      = "\x1b[B"
+	left      = "\x1b[D"
+	home      = "\x1b[H"
+	del       = "\x1b[3~"
+	backspace = "\x7f"
+	ctrlC     = "\x03"
+	ctrlD     = "\x04"
+	ctrlE     = "\x05"
+	ctrlG     = "\x07"
+	ctrlK     = "\x0b"
+	ctrlR     = "\x12"
+	ctrlU     = "\x15"
+	ctrlW     = "\x17"
+	enter     = "\r"
+	tab       = "\t"
+)
+
+func newHistory(lines ...string) *repl.History {
+	h := repl.NewHistory("")
+	for _, line := range lines {
+		h.Add(line)
+	}
+	return h
+}
+
+func TestLineEditing(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		input   string
+		history []string
+		line    string
+	}{
+		{desc: "plain", input: "1 + 2" + enter, line: "1 + 2"},
+		{desc: "insert in the middle", input: "13" + left + "2" + enter, line: "123"},
+		{desc: "backspace", input: "12" + backspace + "3" + enter, line: "13"},
+		{desc: "home and delete", input: "x12" + home + del + ctrlE + "3" + enter, line: "123"},
+		{desc: "kill to end", input: "1 + 2" + left + left + ctrlK + enter, line: "1 +"},
+		{desc: "kill to start", input: "1 + 2" + left + ctrlU + enter, line: "2"},
+		{desc: "delete word", input: "let abc" + ctrlW + "a" + enter, line: "let a"},
+		{desc: "previous line", input: up + enter, history: []string{"a", "b"}, line: "b"},
+		{desc: "older line", input: up + up + enter, history: []string{"a", "b"}, line: "a"},
+		{desc: "back to edited line", input: "x" + up + up + down + down + enter, history: []string{"a", "b"}, line: "x"},
+		{desc: "reverse search", input: ctrlR + "le" + enter, history: []string{"let a = 1", "a + 1", "let b = 2"}, line: "let b = 2"},
+		{desc: "reverse search again", input: ctrlR + "le" + ctrlR + enter, history: []string{"let a = 1", "a + 1", "let b = 2"}, line: "let a = 1"},
+		{desc: "reverse search edit", input: ctrlR + "+" + left + "2" + enter, history: []string{"a + 1", "b"}, line: "a + 21"},
+		{desc: "reverse search cancel", input: "x" + ctrlR + "a" + ctrlG + enter, history: []string{"a"}, line: "x"},
+		{desc: "complete unique", input: "sq" + tab + enter, line: "sqrt "},
+		{desc: "complete common prefix", input: "a" + tab + enter, line: "a"},
+		{desc: "complete longer common prefix", input: "lo" + tab + enter, line: "long_"},
+	}
+	completer := func() []string {
+		return []string{"sqrt", "abs", "and", "long_a", "long_b"}
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			var out strings.Builder
+			e := repl.NewLineEditor(strings.NewReader(tt.input), &out, newHistory(tt.history...), completer)
+			line, err := e.ReadLine(">> ")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if line != tt.line {
+				t.Errorf("expected: %q got: %q", tt.line, line)
+			}
+		})
+	}
+}
+
+func TestLineEditorSignals(t *testing.T) {
+	var out strings.Builder
+	e := repl.NewLineEditor(strings.NewReader("abc"+ctrlC+ctrlD), &out, nil, nil)
+	if _, err := e.ReadLine(">> "); !errors.Is(err, repl.ErrInterrupt) {
+		t.Errorf("Ctrl-C: expected ErrInterrupt got: %v", err)
+	}
+	if _, err := e.ReadLine(">> "); err != io.EOF {
+		t.Errorf("Ctrl-D: expected EOF got: %v", err)
+	}
+}
+
+func TestHistoryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ferret_history")
+	h := repl.NewHistory(path)
+	h.Add("let a = 1")
+	h.Add("let a = 1")
+	h.Add("")
+	h.Add("a + 1")
+
+	h = repl.NewHistory(path)
+	if h.Len() != 2 {
+		t.Fatalf("wrong history length expected: 2 got: %d", h.Len())
+	}
+	if h.At(0) != "let a = 1" || h.At(1) != "a + 1" {
+		t.Errorf("wrong history: %q, %q", h.At(0), h.At(1))
+	}
+}
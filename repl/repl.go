@@ -0,0 +1,190 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/token"
+)
+
+const (
+	PROMPT          = ">> "
+	CONTINUE_PROMPT = ".. "
+)
+
+// Start read statements from in and print results to out.
+// Statement can take several lines: input is evaluated only when it's complete,
+// empty line evaluate it anyway (and show what's wrong).
+// Lines starting with ':' are meta-commands, see :help.
+// If in is a terminal lines are read with LineEditor and saved in ~/.ferret_history
+func Start(in io.Reader, out io.Writer, env *object.Environment) {
+	s := &session{out: out, env: env, strict: env.Strict(), modules: evaluator.NewModules()}
+	r := newLineReader(in, out, s)
+	var source strings.Builder
+
+	prompt := PROMPT
+	for {
+		line, err := r.ReadLine(prompt)
+		if errors.Is(err, ErrInterrupt) {
+			source.Reset()
+			prompt = PROMPT
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if source.Len() == 0 && isCommand(line) {
+			s.runCommand(line)
+			continue
+		}
+		if source.Len() > 0 {
+			source.WriteByte('\n')
+		}
+		source.WriteString(line)
+
+		p := parser.New(lexer.New(source.String()))
+		program := p.Parse()
+		if p.Incomplete() && line != "" {
+			prompt = CONTINUE_PROMPT
+			continue
+		}
+		prompt = PROMPT
+
+		if p.HasErrors() {
+			p.PrintErrors(out)
+		} else {
+			s.run(source.String(), "", program)
+		}
+		source.Reset()
+	}
+}
+
+// session is a state of REPL
+type session struct {
+	out io.Writer
+	env *object.Environment
+
+	// strict is a mode of environment REPL started with, :reset keep it
+	strict bool
+
+	// history is a sources evaluated in session, :save write them to file
+	history []entry
+
+	// results is non-error results, bound in env as _1, _2, ... and the last as _
+	results []object.Object
+
+	// modules are imported once per session, :reset forget them
+	modules *evaluator.Modules
+}
+
+func (s *session) reset() {
+	s.env = object.NewEnv()
+	s.env.SetStrict(s.strict)
+	s.history = nil
+	s.results = nil
+	s.modules = evaluator.NewModules()
+}
+
+// parse source and print errors if any
+func (s *session) parse(source string) (*ast.Program, bool) {
+	p := parser.New(lexer.New(source))
+	program := p.Parse()
+	if p.HasErrors() {
+		p.PrintErrors(s.out)
+		return nil, false
+	}
+	return program, true
+}
+
+// evalIn evaluate program, imports are relative to file or working directory if it's ""
+func (s *session) evalIn(env *object.Environment, file string, program *ast.Program) object.Object {
+	return s.modules.EvalContext(context.Background(), env, program, file, evaluator.Limits{})
+}
+
+// run evaluate program in session environment, print result and record source
+func (s *session) run(source, file string, program *ast.Program) {
+	evaluated := s.evalIn(s.env, file, program)
+	s.print(evaluated)
+	if evaluated != nil && object.IsError(evaluated) {
+		return
+	}
+	e := entry{source: source, program: program}
+	if evaluated != nil {
+		s.results = append(s.results, evaluated)
+		s.env.Set("_", evaluated)
+		s.env.Set(resultName(len(s.results)), evaluated)
+		e.result = len(s.results)
+	}
+	s.history = append(s.history, e)
+}
+
+func resultName(n int) string {
+	return "_" + strconv.Itoa(n)
+}
+
+func (s *session) print(evaluated object.Object) {
+	if evaluated != nil {
+		fmt.Fprintln(s.out, evaluated.Inspect())
+	}
+}
+
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+func newLineReader(in io.Reader, out io.Writer, s *session) lineReader {
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		completer := func() []string {
+			words := token.Keywords()
+			words = append(words, object.BuiltinNames()...)
+			return append(words, s.env.Names()...)
+		}
+		return &terminalReader{
+			fd:     f.Fd(),
+			editor: NewLineEditor(f, out, NewHistory(HistoryPath()), completer),
+		}
+	}
+	return &plainReader{s: bufio.NewScanner(in), out: out}
+}
+
+type plainReader struct {
+	s   *bufio.Scanner
+	out io.Writer
+}
+
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if !r.s.Scan() {
+		if err := r.s.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.s.Text(), nil
+}
+
+// terminalReader keep terminal in raw mode only while line is edited
+type terminalReader struct {
+	fd     uintptr
+	editor *LineEditor
+}
+
+func (r *terminalReader) ReadLine(prompt string) (string, error) {
+	state, err := makeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore(r.fd, state)
+	return r.editor.ReadLine(prompt)
+}
@@ -0,0 +1,52 @@
+package repl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/repl"
+)
+
+func TestMultilineInput(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		input  string
+		output string
+	}{
+		{
+			desc:   "single line",
+			input:  "1 + 2\n",
+			output: ">> 3\n>> ",
+		},
+		{
+			desc:   "block",
+			input:  "{\nlet a = 1\na + 1\n}\n",
+			output: ">> .. .. .. 2\n>> ",
+		},
+		{
+			desc:   "trailing operator",
+			input:  "1 +\n2\n",
+			output: ">> .. 3\n>> ",
+		},
+		{
+			desc:   "unclosed parenthesis",
+			input:  "pow(2,\n3)\n",
+			output: ">> .. 8\n>> ",
+		},
+		{
+			desc:   "empty line stops continuation",
+			input:  "(1 +\n\n1\n",
+			output: ">> .. 2:1: unexpected end of input\n>> 1\n>> ",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			var out strings.Builder
+			repl.Start(strings.NewReader(tt.input), &out, object.NewEnv())
+			if out.String() != tt.output {
+				t.Errorf("expected: %q got: %q", tt.output, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package repl
+
+import "errors"
+
+// line editing isn't supported here, REPL reads plain lines
+
+type terminalState struct{}
+
+func isTerminal(fd uintptr) bool { return false }
+
+func makeRaw(fd uintptr) (*terminalState, error) {
+	return nil, errors.New("raw mode is not supported")
+}
+
+func restore(fd uintptr, state *terminalState) error { return nil }
@@ -0,0 +1,190 @@
+// Package symbolic differentiates and simplifies expressions as trees of ast,
+// so results can be printed, evaluated and differentiated again.
+//
+// Names other than the variable are constants, calls are known only for math builtins.
+// Numbers are reals here: 1 / 2 is folded to 0.5 and rules introduce float constants,
+// so integer division doesn't sneak into derivatives: d(ln(x), x) is 1.0 / x
+package symbolic
+
+import (
+	"fmt"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/format"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Derive return simplified derivative of expr by variable x
+func Derive(expr ast.Expression, x string) (ast.Expression, error) {
+	d, err := derive(expr, x)
+	if err != nil {
+		return nil, err
+	}
+	return Simplify(d), nil
+}
+
+func derive(expr ast.Expression, x string) (ast.Expression, error) {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.BooleanLiteral:
+		return integer(0), nil
+
+	case *ast.Identifier:
+		if expr.Value == x {
+			return integer(1), nil
+		}
+		return integer(0), nil
+
+	case *ast.PrefixExpression:
+		if expr.Operator != "-" {
+			break
+		}
+		du, err := derive(expr.Right, x)
+		if err != nil {
+			return nil, err
+		}
+		return neg(du), nil
+
+	case *ast.PostfixExpression:
+		if expr.Operator != "%" {
+			break
+		}
+		du, err := derive(expr.Left, x)
+		if err != nil {
+			return nil, err
+		}
+		return infix(token.DIV, du, integer(100)), nil
+
+	case *ast.InfixExpression:
+		return deriveInfix(expr, x)
+
+	case *ast.CallExpression:
+		return deriveCall(expr, x)
+	}
+	return nil, fmt.Errorf("can't differentiate %s", format.Node(expr))
+}
+
+func deriveInfix(expr *ast.InfixExpression, x string) (ast.Expression, error) {
+	u, v := expr.Left, expr.Right
+	du, err := derive(u, x)
+	if err != nil {
+		return nil, err
+	}
+	dv, err := derive(v, x)
+	if err != nil {
+		return nil, err
+	}
+	switch expr.Token.Type {
+	case token.ADD, token.SUB:
+		return infix(expr.Token.Type, du, dv), nil
+	case token.MUL:
+		return infix(token.ADD, infix(token.MUL, du, v), infix(token.MUL, u, dv)), nil
+	case token.DIV:
+		// float numerator keeps it from integer division: d(1 / x, x) is -1.0 / x ^ 2
+		numerator := infix(token.SUB, infix(token.MUL, du, v), infix(token.MUL, u, dv))
+		return infix(token.DIV, infix(token.MUL, one(), numerator), infix(token.POW, v, integer(2))), nil
+	case token.POW:
+		// v * u^(v - 1) * u' + u^v * ln(u) * v', one of terms is zero when u or v is constant
+		power := infix(token.MUL, infix(token.MUL, v, infix(token.POW, u, infix(token.SUB, v, integer(1)))), du)
+		exponential := infix(token.MUL, infix(token.MUL, expr, call("ln", u)), dv)
+		switch {
+		case !contains(v, x):
+			return power, nil
+		case !contains(u, x):
+			return exponential, nil
+		}
+		return infix(token.ADD, power, exponential), nil
+	}
+	return nil, fmt.Errorf("can't differentiate %s", format.Node(expr))
+}
+
+// rules are derivatives of builtins of one argument at u
+var rules = map[string]func(u ast.Expression) ast.Expression{
+	"sin": func(u ast.Expression) ast.Expression { return call("cos", u) },
+	"cos": func(u ast.Expression) ast.Expression { return neg(call("sin", u)) },
+	"tan": func(u ast.Expression) ast.Expression {
+		return infix(token.DIV, one(), infix(token.POW, call("cos", u), integer(2)))
+	},
+	"exp": func(u ast.Expression) ast.Expression { return call("exp", u) },
+	"ln":  func(u ast.Expression) ast.Expression { return infix(token.DIV, one(), u) },
+	"log10": func(u ast.Expression) ast.Expression {
+		return infix(token.DIV, one(), infix(token.MUL, u, call("ln", integer(10))))
+	},
+	"sqrt": func(u ast.Expression) ast.Expression {
+		return infix(token.DIV, one(), infix(token.MUL, integer(2), call("sqrt", u)))
+	},
+	"asin": func(u ast.Expression) ast.Expression {
+		return infix(token.DIV, one(), call("sqrt", infix(token.SUB, integer(1), infix(token.POW, u, integer(2)))))
+	},
+	"acos": func(u ast.Expression) ast.Expression {
+		return neg(infix(token.DIV, one(), call("sqrt", infix(token.SUB, integer(1), infix(token.POW, u, integer(2))))))
+	},
+	"atan": func(u ast.Expression) ast.Expression {
+		return infix(token.DIV, one(), infix(token.ADD, integer(1), infix(token.POW, u, integer(2))))
+	},
+	"abs": func(u ast.Expression) ast.Expression { return infix(token.DIV, u, call("abs", u)) },
+}
+
+// Known report if derivative of builtin name is known
+func Known(name string) bool {
+	_, ok := rules[name]
+	return ok || name == "pow"
+}
+
+// deriveCall apply chain rule: f(u)' = f'(u) * u'
+func deriveCall(expr *ast.CallExpression, x string) (ast.Expression, error) {
+	ident, ok := expr.Function.(*ast.Identifier)
+	if !ok || !Known(ident.Value) {
+		return nil, fmt.Errorf("can't differentiate %s: derivative of %s is unknown", format.Node(expr), format.Node(expr.Function))
+	}
+	if ident.Value == "pow" {
+		if len(expr.Arguments) != 2 {
+			return nil, fmt.Errorf("pow: expected 2 arguments got %d", len(expr.Arguments))
+		}
+		return deriveInfix(infix(token.POW, expr.Arguments[0], expr.Arguments[1]), x)
+	}
+	if len(expr.Arguments) != 1 {
+		return nil, fmt.Errorf("%s: expected 1 arguments got %d", ident.Value, len(expr.Arguments))
+	}
+	u := expr.Arguments[0]
+	du, err := derive(u, x)
+	if err != nil {
+		return nil, err
+	}
+	return infix(token.MUL, rules[ident.Value](u), du), nil
+}
+
+// contains report if expr depends on x
+func contains(expr ast.Expression, x string) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok && ident.Value == x {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// Replace return copy of expr with variable x replaced by value,
+// names of called functions aren't variables: x(1) is left as is
+func Replace(expr ast.Expression, x string, value ast.Expression) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		if expr.Value == x {
+			return value
+		}
+	case *ast.PrefixExpression:
+		return &ast.PrefixExpression{Token: expr.Token, Operator: expr.Operator, Right: Replace(expr.Right, x, value)}
+	case *ast.PostfixExpression:
+		return &ast.PostfixExpression{Token: expr.Token, Operator: expr.Operator, Left: Replace(expr.Left, x, value)}
+	case *ast.InfixExpression:
+		return &ast.InfixExpression{Token: expr.Token, Operator: expr.Operator, Left: Replace(expr.Left, x, value), Right: Replace(expr.Right, x, value)}
+	case *ast.CallExpression:
+		args := make([]ast.Expression, len(expr.Arguments))
+		for i, arg := range expr.Arguments {
+			args[i] = Replace(arg, x, value)
+		}
+		return &ast.CallExpression{Token: expr.Token, Function: expr.Function, Arguments: args, Piped: expr.Piped}
+	}
+	return expr
+}
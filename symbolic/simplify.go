@@ -0,0 +1,359 @@
+package symbolic
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Simplify return algebraically simplified copy of expr: constants are folded,
+// neutral operands (x + 0, 1 * x, x ^ 1) and zero products are removed,
+// constants are moved to the front of products and equal operands are combined (x * x is x ^ 2)
+func Simplify(expr ast.Expression) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		return simplifyInfix(expr.Token.Type, Simplify(expr.Left), Simplify(expr.Right))
+	case *ast.PrefixExpression:
+		right := Simplify(expr.Right)
+		if expr.Operator == "-" {
+			return negate(right)
+		}
+		return &ast.PrefixExpression{Token: expr.Token, Operator: expr.Operator, Right: right}
+	case *ast.PostfixExpression:
+		return &ast.PostfixExpression{Token: expr.Token, Operator: expr.Operator, Left: Simplify(expr.Left)}
+	case *ast.CallExpression:
+		args := make([]ast.Expression, len(expr.Arguments))
+		for i, arg := range expr.Arguments {
+			args[i] = Simplify(arg)
+		}
+		return &ast.CallExpression{Token: token.NoLiteralToken(token.LPAREN), Function: expr.Function, Arguments: args}
+	}
+	return expr
+}
+
+func simplifyInfix(op token.TokenType, l, r ast.Expression) ast.Expression {
+	lv, lok := value(l)
+	rv, rok := value(r)
+	if lok && rok {
+		if res, ok := fold(op, lv, rv); ok {
+			return res
+		}
+	}
+	lNeg, lIsNeg := negated(l)
+	rNeg, rIsNeg := negated(r)
+
+	switch op {
+	case token.ADD, token.SUB:
+		return sum(terms(infix(op, l, r), 1, nil))
+	case token.MUL:
+		// float 1 is kept, it makes division after it a float one
+		switch {
+		case lok && lv.v == 0, rok && rv.v == 0:
+			return integer(0)
+		case lok && lv.v == 1 && !lv.float:
+			return r
+		case rok && rv.v == 1 && !rv.float:
+			return l
+		case lok && lv.v == -1 && !lv.float:
+			return negate(r)
+		case lIsNeg && !lok:
+			return negate(simplifyInfix(token.MUL, lNeg, r))
+		case rIsNeg && !rok:
+			return negate(simplifyInfix(token.MUL, l, rNeg))
+		case rok && !lok:
+			return simplifyInfix(token.MUL, r, l)
+		case equal(l, r):
+			return simplifyInfix(token.POW, l, integer(2))
+		}
+		// c1 * (c2 / u) is (c1 * c2) / u
+		if quo, ok := r.(*ast.InfixExpression); ok && quo.Token.Is(token.DIV) && lok {
+			if c, ok := value(quo.Left); ok {
+				if c, ok := fold(token.MUL, lv, c); ok {
+					return simplifyInfix(token.DIV, c, quo.Right)
+				}
+			}
+		}
+		// u * (c * v) is c * u * v, c1 * (c2 * u) is (c1 * c2) * u
+		if prod, ok := r.(*ast.InfixExpression); ok && prod.Token.Is(token.MUL) {
+			if c, ok := value(prod.Left); ok {
+				return simplifyInfix(token.MUL, simplifyInfix(token.MUL, literal(c), l), prod.Right)
+			}
+		}
+		// u * u^n is u^(n + 1)
+		if pow, ok := r.(*ast.InfixExpression); ok && pow.Token.Is(token.POW) && equal(l, pow.Left) {
+			return simplifyInfix(token.POW, l, simplifyInfix(token.ADD, pow.Right, integer(1)))
+		}
+		if pow, ok := l.(*ast.InfixExpression); ok && pow.Token.Is(token.POW) && equal(r, pow.Left) {
+			return simplifyInfix(token.POW, r, simplifyInfix(token.ADD, pow.Right, integer(1)))
+		}
+	case token.DIV:
+		switch {
+		case lok && lv.v == 0:
+			return integer(0)
+		case rok && rv.v == 1 && !rv.float:
+			return l
+		case lIsNeg && !lok:
+			return negate(simplifyInfix(token.DIV, lNeg, r))
+		case rIsNeg:
+			return negate(simplifyInfix(token.DIV, l, rNeg))
+		case equal(l, r):
+			return integer(1)
+		}
+		// c1 / (c2 * u) is (c1 / c2) / u
+		if prod, ok := r.(*ast.InfixExpression); ok && prod.Token.Is(token.MUL) && lok {
+			if c, ok := value(prod.Left); ok {
+				if c, ok := fold(token.DIV, lv, c); ok {
+					return simplifyInfix(token.DIV, c, prod.Right)
+				}
+			}
+		}
+		// c1 * u / c2 is (c1 / c2) * u, float 1 of quotient rule isn't needed after it: 1.0 * 4 * x / 4 is x
+		if prod, ok := l.(*ast.InfixExpression); ok && prod.Token.Is(token.MUL) && rok {
+			if c, ok := value(prod.Left); ok {
+				c.float = c.float && c.v != math.Trunc(c.v)
+				if c, ok := fold(token.DIV, c, rv); ok {
+					return simplifyInfix(token.MUL, c, prod.Right)
+				}
+			}
+		}
+	case token.POW:
+		switch {
+		case rok && rv.v == 0, lok && lv.v == 1:
+			return integer(1)
+		case rok && rv.v == 1:
+			return l
+		}
+		// (u^a)^n is u^(a * n) for integer n
+		if pow, ok := l.(*ast.InfixExpression); ok && pow.Token.Is(token.POW) && rok && !rv.float {
+			return simplifyInfix(token.POW, pow.Left, simplifyInfix(token.MUL, pow.Right, r))
+		}
+	}
+	return infix(op, l, r)
+}
+
+// term of sum is coef * expr, expr is nil for constant
+type term struct {
+	coef number
+	expr ast.Expression
+}
+
+// terms flatten sum to terms multiplied by sign
+func terms(expr ast.Expression, sign float64, res []term) []term {
+	if n, ok := value(expr); ok {
+		n.v *= sign
+		return append(res, term{coef: n})
+	}
+	if in, ok := expr.(*ast.InfixExpression); ok && (in.Token.Is(token.ADD) || in.Token.Is(token.SUB)) {
+		res = terms(in.Left, sign, res)
+		if in.Token.Is(token.SUB) {
+			sign = -sign
+		}
+		return terms(in.Right, sign, res)
+	}
+	if u, ok := negated(expr); ok {
+		return terms(u, -sign, res)
+	}
+	coef := number{v: 1}
+	if prod, ok := expr.(*ast.InfixExpression); ok && prod.Token.Is(token.MUL) {
+		if n, ok := value(prod.Left); ok {
+			coef, expr = n, prod.Right
+		}
+	}
+	coef.v *= sign
+	return append(res, term{coef: coef, expr: expr})
+}
+
+// sum combine like terms, constant goes last: x + 1 - x + 2x is 2 * x + 1
+func sum(ts []term) ast.Expression {
+	var combined []term
+	constant := term{}
+	for _, t := range ts {
+		if t.expr == nil {
+			constant.coef = add(constant.coef, t.coef)
+			continue
+		}
+		i := slices.IndexFunc(combined, func(c term) bool { return equal(c.expr, t.expr) })
+		if i < 0 {
+			combined = append(combined, t)
+		} else {
+			combined[i].coef = add(combined[i].coef, t.coef)
+		}
+	}
+	combined = append(combined, constant)
+
+	var res ast.Expression
+	for _, t := range combined {
+		switch {
+		case t.coef.v == 0:
+		case res == nil:
+			res = t.product()
+		case t.coef.v < 0:
+			t.coef.v = -t.coef.v
+			res = infix(token.SUB, res, t.product())
+		default:
+			res = infix(token.ADD, res, t.product())
+		}
+	}
+	if res == nil {
+		return literal(constant.coef)
+	}
+	return res
+}
+
+func add(a, b number) number {
+	return number{v: a.v + b.v, float: a.float || b.float}
+}
+
+func (t term) product() ast.Expression {
+	switch {
+	case t.expr == nil:
+		return literal(t.coef)
+	case t.coef.v == 1:
+		return t.expr
+	case t.coef.v == -1:
+		return negate(t.expr)
+	}
+	return infix(token.MUL, literal(t.coef), t.expr)
+}
+
+// number is a value of literal, negative one is -literal
+type number struct {
+	v     float64
+	float bool
+}
+
+func value(expr ast.Expression) (number, bool) {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return number{v: float64(expr.Value)}, true
+	case *ast.FloatLiteral:
+		return number{v: expr.Value, float: true}, true
+	case *ast.PrefixExpression:
+		if n, ok := value(expr.Right); ok && expr.Operator == "-" {
+			n.v = -n.v
+			return n, true
+		}
+	}
+	return number{}, false
+}
+
+// maxExact is the largest float64 that integers are exact up to
+const maxExact = 1 << 53
+
+// fold compute operation on numbers, it fails if result is not a finite number
+// or integer would lose precision
+func fold(op token.TokenType, l, r number) (ast.Expression, bool) {
+	res := number{float: l.float || r.float}
+	switch op {
+	case token.ADD:
+		res.v = l.v + r.v
+	case token.SUB:
+		res.v = l.v - r.v
+	case token.MUL:
+		res.v = l.v * r.v
+	case token.DIV:
+		if r.v == 0 {
+			return nil, false
+		}
+		res.v = l.v / r.v
+		res.float = res.float || res.v != math.Trunc(res.v)
+	case token.POW:
+		res.v = math.Pow(l.v, r.v)
+		res.float = res.float || r.v < 0 || res.v != math.Trunc(res.v)
+	default:
+		return nil, false
+	}
+	if math.IsInf(res.v, 0) || math.IsNaN(res.v) || !res.float && math.Abs(res.v) > maxExact {
+		return nil, false
+	}
+	return literal(res), true
+}
+
+// negated return u if expr is -u, product or quotient with negative coefficient
+// like -2 * x / y is negated 2 * x / y
+func negated(expr ast.Expression) (ast.Expression, bool) {
+	switch expr := expr.(type) {
+	case *ast.PrefixExpression:
+		if expr.Operator == "-" {
+			return expr.Right, true
+		}
+	case *ast.InfixExpression:
+		if expr.Token.Is(token.MUL) || expr.Token.Is(token.DIV) {
+			if u, ok := negated(expr.Left); ok {
+				return infix(expr.Token.Type, u, expr.Right), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// negate return simplified -expr, minus of product goes to its coefficient: -(2 * x) is -2 * x
+func negate(expr ast.Expression) ast.Expression {
+	if n, ok := value(expr); ok {
+		n.v = -n.v
+		return literal(n)
+	}
+	if u, ok := negated(expr); ok {
+		return u
+	}
+	if coefficient(expr) {
+		prod := expr.(*ast.InfixExpression)
+		return infix(prod.Token.Type, negate(prod.Left), prod.Right)
+	}
+	return neg(expr)
+}
+
+// coefficient report if expr is a number or product or quotient that starts with it
+func coefficient(expr ast.Expression) bool {
+	if _, ok := value(expr); ok {
+		return true
+	}
+	prod, ok := expr.(*ast.InfixExpression)
+	return ok && (prod.Token.Is(token.MUL) || prod.Token.Is(token.DIV)) && coefficient(prod.Left)
+}
+
+// equal report if expressions are the same tree
+func equal(a, b ast.Expression) bool {
+	return a.String() == b.String()
+}
+
+func literal(n number) ast.Expression {
+	if n.v < 0 {
+		return neg(literal(number{v: -n.v, float: n.float}))
+	}
+	if !n.float {
+		return integer(int64(n.v))
+	}
+	s := strconv.FormatFloat(n.v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return &ast.FloatLiteral{Token: token.Token{Type: token.FLOAT, Literal: s}, Value: n.v}
+}
+
+func integer(v int64) ast.Expression {
+	return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: strconv.FormatInt(v, 10)}, Value: v}
+}
+
+// one is float 1, numerator of derivatives that would be integer division otherwise
+func one() ast.Expression {
+	return literal(number{v: 1, float: true})
+}
+
+func infix(op token.TokenType, l, r ast.Expression) *ast.InfixExpression {
+	tok := token.NoLiteralToken(op)
+	return &ast.InfixExpression{Token: tok, Left: l, Operator: tok.Literal, Right: r}
+}
+
+func neg(expr ast.Expression) ast.Expression {
+	return &ast.PrefixExpression{Token: token.NoLiteralToken(token.SUB), Operator: "-", Right: expr}
+}
+
+func call(name string, args ...ast.Expression) ast.Expression {
+	function := &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+	return &ast.CallExpression{Token: token.NoLiteralToken(token.LPAREN), Function: function, Arguments: args}
+}
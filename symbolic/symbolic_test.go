@@ -0,0 +1,144 @@
+package symbolic_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/format"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/symbolic"
+)
+
+func parse(t *testing.T, source string) ast.Expression {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.Parse()
+	if p.HasErrors() {
+		t.Fatal(p.Errors())
+	}
+	return program.Statements[0].(*ast.ExpressionStatement).Expr
+}
+
+func TestDerive(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"x^2 * sin(x)", "2 * x * sin(x) + x ^ 2 * cos(x)"},
+		{"5", "0"},
+		{"y", "0"},
+		{"3x - 5x", "-2"},
+		{"a*x^2 + b*x + c", "2 * a * x + b"},
+		{"x^3", "3 * x ^ 2"},
+		{"x^-1", "-x ^ (-2)"},
+		{"x^2 / 2", "x"},
+		{"1 / x", "-1.0 / x ^ 2"},
+		{"x / (x + 1)", "1.0 / (x + 1) ^ 2"},
+		{"2^x", "2 ^ x * ln(2)"},
+		{"x^x", "x ^ x + x ^ x * ln(x)"},
+		{"pow(x, 3)", "3 * x ^ 2"},
+		{"ln(x)", "1.0 / x"},
+		{"ln(2x)", "1.0 / x"},
+		{"sqrt(x)", "0.5 / sqrt(x)"},
+		{"exp(2x)", "2 * exp(2 * x)"},
+		{"cos(x)", "-sin(x)"},
+		{"sin(x^2)", "2 * cos(x ^ 2) * x"},
+		{"tan(3x)", "3.0 / cos(3 * x) ^ 2"},
+		{"-x", "-1"},
+		{"50x%", "0.5"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			res, err := symbolic.Derive(parse(t, tt.source), "x")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := format.Node(res); s != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestDeriveErrors(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"x!", "can't differentiate x!"},
+		{"x > 1", "can't differentiate x > 1"},
+		{"floor(x)", "can't differentiate floor(x): derivative of floor is unknown"},
+		{"sin(x, 1)", "sin: expected 1 arguments got 2"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := symbolic.Derive(parse(t, tt.source), "x")
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected %s got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// derivatives agree with finite differences
+func TestDeriveNumerically(t *testing.T) {
+	sources := []string{
+		"x^2 * sin(x)", "1 / (x^2 + 1)", "sqrt(x^3 + 1)", "exp(-x^2 / 2)", "x^x",
+		"atan(2x) - asin(x / 2)", "ln(x) * log10(x)", "tan(x) / x", "abs(x - 3)", "2^x * acos(x / 4)",
+	}
+	const h = 1e-6
+	for _, source := range sources {
+		t.Run(source, func(t *testing.T) {
+			expr := parse(t, source)
+			derivative, err := symbolic.Derive(expr, "x")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, x := range []float64{0.5, 1, 2} {
+				expected := (eval(t, expr, x+h) - eval(t, expr, x-h)) / (2 * h)
+				if res := eval(t, derivative, x); math.Abs(res-expected) > 1e-5*math.Max(1, math.Abs(expected)) {
+					t.Errorf("at %v: expected %v got %v (%s)", x, expected, res, format.Node(derivative))
+				}
+			}
+		})
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{"0 * x + 1 * y - 0", "y"},
+		{"x + 1 - x + 2x", "2 * x + 1"},
+		{"x * x * 3", "3 * x ^ 2"},
+		{"(x^2)^3", "x ^ 6"},
+		{"2 * (3 * x)", "6 * x"},
+		{"-(2 * x) / y", "-2 * x / y"},
+		{"x / x + 1 / 2", "1.5"},
+		{"a - b - (c - d)", "a - b - c + d"},
+		{"sin(0 + x) ^ 1", "sin(x)"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			if s := format.Node(symbolic.Simplify(parse(t, tt.source))); s != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, s)
+			}
+		})
+	}
+}
+
+func eval(t *testing.T, expr ast.Expression, x float64) float64 {
+	t.Helper()
+	env := object.NewEnv()
+	env.Set("x", &object.Float{Value: x})
+	res, ok := object.AsNative(evaluator.Eval(env, expr))
+	if !ok {
+		t.Fatalf("%s is not a number at %v", format.Node(expr), x)
+	}
+	return res
+}
@@ -19,6 +19,14 @@ func (t TokenType) IsKeyword() bool {
 	return keywords_begin < t && t < keywords_end
 }
 
+func (t TokenType) IsComparison() bool {
+	switch t {
+	case EQ, NEQ, GT, GEQ, LT, LEQ:
+		return true
+	}
+	return false
+}
+
 func (t TokenType) Is(t2 TokenType) bool {
 	return t == t2
 }
@@ -31,13 +39,15 @@ const (
 	ILLEGAL TokenType = iota
 	EOF
 	LF
+	PRAGMA // #strict
 
 	// cool idea, that i stole from go source code
 	literal_begin
-	IDENT // a
-	INT   // 2
-	FLOAT // 2.5
-	BOOL  // true | false
+	IDENT  // a
+	INT    // 2
+	FLOAT  // 2.5
+	BOOL   // true | false
+	STRING // "stats.fe", only path of import
 	literal_end
 
 	operators_begin
@@ -46,10 +56,13 @@ const (
 	MUL       // *
 	DIV       // /
 	REM       // %
+	POW       // ^
 	LPAREN    // (
 	RPAREN    // )
 	LBRACE    // {
 	RBRACE    // }
+	LBRACKET  // [
+	RBRACKET  // ]
 	SEMICOLON // ;
 	ASSIGN    // =
 	EQ        // ==
@@ -59,16 +72,24 @@ const (
 	GEQ       // >=
 	LT        // <
 	LEQ       // <=
+	COMMA     // ,
+	PIPE      // |>
+	COLON     // :
+	ARROW     // ->
+	DOT       // .
 	operators_end
 
 	keywords_begin
-	LET   // let
-	IF    // if
-	ELSE  // else
-	TRUE  // true
-	FALSE // false
-	AND   // and
-	OR    // or
+	LET    // let
+	IF     // if
+	ELSE   // else
+	TRUE   // true
+	FALSE  // false
+	AND    // and
+	OR     // or
+	FN     // fn
+	IMPORT // import
+	AS     // as
 	keywords_end
 )
 
@@ -76,21 +97,26 @@ var tokens = [...]string{
 	ILLEGAL: "ILLEGAL",
 	EOF:     "EOF",
 	LF:      "\\n",
+	PRAGMA:  "pragma",
 
-	IDENT: "ident",
-	INT:   "int",
-	FLOAT: "float",
-	BOOL:  "bool",
+	IDENT:  "ident",
+	INT:    "int",
+	FLOAT:  "float",
+	BOOL:   "bool",
+	STRING: "string",
 
 	ADD:       "+",
 	SUB:       "-",
 	MUL:       "*",
 	DIV:       "/",
 	REM:       "%",
+	POW:       "^",
 	LPAREN:    "(",
 	RPAREN:    ")",
 	LBRACE:    "{",
 	RBRACE:    "}",
+	LBRACKET:  "[",
+	RBRACKET:  "]",
 	SEMICOLON: ";",
 	ASSIGN:    "=",
 	EQ:        "==",
@@ -100,27 +126,69 @@ var tokens = [...]string{
 	GEQ:       ">=",
 	LT:        "<",
 	LEQ:       "<=",
+	COMMA:     ",",
+	PIPE:      "|>",
+	COLON:     ":",
+	ARROW:     "->",
+	DOT:       ".",
 
-	LET:   "let",
-	IF:    "if",
-	ELSE:  "else",
-	TRUE:  "true",
-	FALSE: "false",
-	AND:   "and",
-	OR:    "or",
+	LET:    "let",
+	IF:     "if",
+	ELSE:   "else",
+	TRUE:   "true",
+	FALSE:  "false",
+	AND:    "and",
+	OR:     "or",
+	FN:     "fn",
+	IMPORT: "import",
+	AS:     "as",
 }
 
 // vim replace command for <TokenType> // <litetal> -> "<literal>": <TokenType>
 // s/\(\w\+\)\s\+\/\/\s\+\(.\+\)/"\2": \1,
 
 var keywords = map[string]TokenType{
-	"let":   LET,
-	"if":    IF,
-	"else":  ELSE,
-	"true":  TRUE,
-	"false": FALSE,
-	"and":   AND,
-	"or":    OR,
+	"let":    LET,
+	"if":     IF,
+	"else":   ELSE,
+	"true":   TRUE,
+	"false":  FALSE,
+	"and":    AND,
+	"or":     OR,
+	"fn":     FN,
+	"import": IMPORT,
+	"as":     AS,
+}
+
+var pragmas = map[string]bool{
+	"strict": true,
+}
+
+// IsPragma report if name is a known pragma (#strict)
+func IsPragma(name string) bool {
+	return pragmas[name]
+}
+
+// typeNames are types of annotations: let rate: float = 0.05
+var typeNames = map[string]bool{
+	"int":    true,
+	"float":  true,
+	"bool":   true,
+	"vector": true,
+}
+
+// IsTypeName report if name is a type that can be used in annotation
+func IsTypeName(name string) bool {
+	return typeNames[name]
+}
+
+// Keywords return all keywords
+func Keywords() []string {
+	res := make([]string, 0, len(keywords))
+	for keyword := range keywords {
+		res = append(res, keyword)
+	}
+	return res
 }
 
 // LookupKeyword lookup in keywords table
@@ -135,6 +203,84 @@ func LookupKeyword(literal string) TokenType {
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Pos
+
+	// Trivia is comments before token, nil if there are none
+	Trivia *Trivia
+}
+
+// Pos is a position in source, line and column start from 1, column is in bytes.
+// Zero Pos means unknown position: tokens made by parser
+type Pos struct {
+	Line int
+	Col  int
+}
+
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Span is a part of source from Start to End (exclusive)
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// Contains report if pos is inside of span
+func (s Span) Contains(pos Pos) bool {
+	return !pos.Less(s.Start) && pos.Less(s.End)
+}
+
+// Less report if p is before p2
+func (p Pos) Less(p2 Pos) bool {
+	return p.Line < p2.Line || p.Line == p2.Line && p.Col < p2.Col
+}
+
+// Span return part of source token takes, tokens don't span lines
+func (t Token) Span() Span {
+	end := t.Pos
+	switch t.Type {
+	case EOF:
+	case PRAGMA:
+		end.Col += len(t.Literal) + 1 // '#'
+	case STRING:
+		end.Col += len(t.Literal) + 2 // quotes
+	default:
+		end.Col += len(t.Literal)
+	}
+	return Span{Start: t.Pos, End: end}
+}
+
+// Comment is a line (# ...) or block (/* ... */) comment
+type Comment struct {
+	Text string // with # or /* */
+	Line int    // line where comment starts
+
+	// OwnLine true if comment isn't preceded by anything on its line
+	OwnLine bool
+}
+
+// Trivia is comments lexer attach to the next token
+type Trivia struct {
+	Comments []Comment
+}
+
+// JoinTrivia return trivia with comments of a followed by comments of b,
+// parser use it to keep comments of tokens that don't get into ast
+func JoinTrivia(a, b *Trivia) *Trivia {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	comments := make([]Comment, 0, len(a.Comments)+len(b.Comments))
+	comments = append(comments, a.Comments...)
+	return &Trivia{Comments: append(comments, b.Comments...)}
 }
 
 func (t Token) IsLiteral() bool {
@@ -149,6 +295,10 @@ func (t Token) IsKeyword() bool {
 	return keywords_begin < t.Type && t.Type < keywords_end
 }
 
+func (t Token) IsComparison() bool {
+	return t.Type.IsComparison()
+}
+
 func (t Token) Is(t2 TokenType) bool {
 	return t.Type == t2
 }
@@ -160,27 +310,37 @@ func (t Token) String() string {
 // TODO: use smaller numbers for precedence
 // https://en.cppreference.com/w/c/language/operator_precedence
 const (
-	LOWEST  = 0
-	UNARY   = 90
-	HIGHEST = 100
+	LOWEST   = 0
+	UNARY    = 90
+	IMPLICIT = 92 // multiplication by juxtaposition: 2x, 3(a + b)
+	POWER    = 93 // right associative: 2^3^2 is 2^(3^2), -x^2 is -(x^2)
+	POSTFIX  = 94
+	CALL     = 95
+	HIGHEST  = 100
 )
 
 func (t Token) Precedence() int {
 	switch t.Type {
-	case OR:
+	case PIPE:
 		return 1
-	case AND:
+	case OR:
 		return 2
-	case EQ, NEQ:
+	case AND:
 		return 3
-	case GT, GEQ, LT, LEQ:
+	case EQ, NEQ, GT, GEQ, LT, LEQ:
+		// same level for all of them to chain: 0 < x <= y == z
 		return 4
 	case ADD, SUB:
 		return 5
 	case MUL, DIV, REM:
 		return 6
+	case POW:
+		return POWER
 	case NOT:
-		return UNARY
+		// in infix position ! is a postfix factorial
+		return POSTFIX
+	case LPAREN, DOT:
+		return CALL
 	default:
 		return LOWEST
 	}
@@ -0,0 +1,268 @@
+package types
+
+import (
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+type Options struct {
+	// Strict is strict mode of the whole program, like -strict flag
+	Strict bool
+
+	// Globals are types of names defined outside of program, like interpreter bindings.
+	// Other names that are not bound by let or builtins are reported as undefined
+	Globals map[string]Type
+}
+
+// Info is result of inference
+type Info struct {
+	// Types of checked expressions
+	Types map[ast.Expression]Type
+}
+
+// TypeOf return type of expression, unknown if it wasn't checked
+func (info *Info) TypeOf(expr ast.Expression) Type {
+	return info.Types[expr]
+}
+
+// Check infer types of program and report operations that always fail.
+// Checker doesn't evaluate anything, values like 1 / 0 are up to optimizer
+func Check(program *ast.Program, opts Options) (*Info, []diag.Diagnostic) {
+	c := &checker{opts: opts, info: &Info{Types: make(map[ast.Expression]Type)}}
+	c.statements(program.Statements, &scope{names: make(map[string]Type), strict: opts.Strict})
+	return c.info, c.diagnostics
+}
+
+type checker struct {
+	opts        Options
+	info        *Info
+	diagnostics []diag.Diagnostic
+}
+
+// scope is types of names bound by let in block, like environment in evaluator
+type scope struct {
+	names  map[string]Type
+	parent *scope
+	strict bool
+
+	// function is scope of function body, names outside of it are looked up
+	// at call time, so they may be rebound or declared after function
+	function bool
+}
+
+func (s *scope) sub() *scope {
+	return &scope{names: make(map[string]Type), parent: s, strict: s.strict}
+}
+
+func (s *scope) lookup(name string) (Type, bool) {
+	inFunction := false
+	for ; s != nil; s = s.parent {
+		if t, ok := s.names[name]; ok {
+			if inFunction {
+				return UnknownType, true
+			}
+			return t, true
+		}
+		inFunction = inFunction || s.function
+	}
+	return UnknownType, false
+}
+
+// inFunction report if scope is in body of function
+func (s *scope) inFunction() bool {
+	for ; s != nil; s = s.parent {
+		if s.function {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) statements(stmts []ast.Statement, sc *scope) {
+	for _, stmt := range stmts {
+		c.statement(stmt, sc)
+	}
+}
+
+func (c *checker) statement(stmt ast.Statement, sc *scope) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		t := c.expression(stmt.Value, sc)
+		if stmt.Type != nil {
+			var p *problem
+			if t, p = assign(stmt.Name.Value, t, annotation(stmt.Type.Name)); p != nil {
+				c.report(ast.Span(stmt.Value), p)
+			}
+		}
+		sc.names[stmt.Name.Value] = t
+	case *ast.FunctionStatement:
+		c.function(stmt, sc)
+	case *ast.ImportStatement:
+		// modules are not checked, their members are unknown
+		sc.names[stmt.Alias.Value] = UnknownType
+	case *ast.ExpressionStatement:
+		c.expression(stmt.Expr, sc)
+	case *ast.BlockStatement:
+		c.statements(stmt.Statements, sc.sub())
+	case *ast.PragmaStatement:
+		if stmt.Name == "strict" {
+			sc.strict = true
+		}
+	case *ast.IfStatement:
+		c.expression(stmt.Condition, sc)
+		c.statements(stmt.Consequence.Statements, sc.sub())
+		if stmt.Alternative != nil {
+			c.statements(stmt.Alternative.Statements, sc.sub())
+		}
+	}
+}
+
+// function bind type of function and check its body with parameters of annotated types
+func (c *checker) function(fn *ast.FunctionStatement, sc *scope) {
+	params := make([]Param, len(fn.Parameters))
+	body := sc.sub()
+	body.function = true
+	for i, param := range fn.Parameters {
+		params[i] = Param{Name: param.Name.Value}
+		if param.Type != nil {
+			params[i].Type = annotation(param.Type.Name)
+		}
+		body.names[param.Name.Value] = params[i].Type
+	}
+	c.statements(fn.Body.Statements, body)
+	result := UnknownType
+	var last ast.Expression
+	if n := len(fn.Body.Statements); n > 0 {
+		if stmt, ok := fn.Body.Statements[n-1].(*ast.ExpressionStatement); ok {
+			last = stmt.Expr
+			result = c.info.TypeOf(last)
+		}
+	}
+	if fn.ReturnType != nil {
+		var p *problem
+		result, p = assign(fn.Name.Value+": result", result, annotation(fn.ReturnType.Name))
+		if p != nil && last != nil {
+			c.report(ast.Span(last), p)
+		}
+	}
+	sc.names[fn.Name.Value] = FunctionOf(fn.Name.Value, params, result)
+}
+
+func (c *checker) expression(expr ast.Expression, sc *scope) Type {
+	t, p := c.infer(expr, sc)
+	if p != nil {
+		c.report(ast.Span(expr), p)
+		// reported once, operations with it are unknown
+		t = UnknownType
+	}
+	c.info.Types[expr] = t
+	return t
+}
+
+func (c *checker) report(span token.Span, p *problem) {
+	d := diag.Errorf(span, "%s", p.message)
+	d.Hint = p.hint
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+func (c *checker) infer(expr ast.Expression, sc *scope) (Type, *problem) {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return IntType, nil
+	case *ast.FloatLiteral:
+		return FloatType, nil
+	case *ast.BooleanLiteral:
+		return BoolType, nil
+
+	case *ast.Identifier:
+		if t, ok := sc.lookup(expr.Value); ok {
+			return t, nil
+		}
+		if t, ok := c.opts.Globals[expr.Value]; ok {
+			return t, nil
+		}
+		if _, ok := object.LookupBuiltin(expr.Value); ok {
+			return BuiltinOf(expr.Value), nil
+		}
+		if sc.inFunction() {
+			// it may be declared before the call
+			return UnknownType, nil
+		}
+		return UnknownType, &problem{message: "undefined: " + expr.Value}
+
+	case *ast.VectorLiteral:
+		return c.vector(expr, sc), nil
+
+	case *ast.PrefixExpression:
+		return prefix(expr.Operator, c.expression(expr.Right, sc), sc.strict)
+
+	case *ast.PostfixExpression:
+		return postfix(expr.Operator, c.expression(expr.Left, sc), sc.strict)
+
+	case *ast.InfixExpression:
+		left := c.expression(expr.Left, sc)
+		right := c.expression(expr.Right, sc)
+		return infix(expr.Token, left, right, sc.strict)
+
+	case *ast.ComparisonChain:
+		left := c.expression(expr.Operands[0], sc)
+		for i, op := range expr.Operators {
+			right := c.expression(expr.Operands[i+1], sc)
+			if _, p := infix(op, left, right, sc.strict); p != nil {
+				return UnknownType, p
+			}
+			left = right
+		}
+		return BoolType, nil
+
+	case *ast.SelectorExpression:
+		c.expression(expr.X, sc)
+		return UnknownType, nil
+
+	case *ast.CallExpression:
+		if c.isDerive(expr, sc) {
+			// arguments of d(expr, x) are not evaluated, x is free there
+			return SymbolicType, nil
+		}
+		function := c.expression(expr.Function, sc)
+		args := make([]Type, len(expr.Arguments))
+		for i, arg := range expr.Arguments {
+			args[i] = c.expression(arg, sc)
+		}
+		return call(function, args, sc.strict)
+	}
+	return UnknownType, nil
+}
+
+// isDerive report if call is d(expr, x) with unbound d like evaluator.IsDerive
+func (c *checker) isDerive(call *ast.CallExpression, sc *scope) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok || ident.Value != "d" {
+		return false
+	}
+	_, bound := sc.lookup("d")
+	_, global := c.opts.Globals["d"]
+	return !bound && !global
+}
+
+// vector infer type of literal, rows of different length are reported as warning:
+// it's a valid vector of vectors, but not a matrix
+func (c *checker) vector(vec *ast.VectorLiteral, sc *scope) Type {
+	if len(vec.Elements) == 0 {
+		return VectorOf(UnknownType, 0)
+	}
+	elem := c.expression(vec.Elements[0], sc)
+	first := elem
+	for _, el := range vec.Elements[1:] {
+		t := c.expression(el, sc)
+		if first.Kind == Vector && t.Kind == Vector && first.Len >= 0 && t.Len >= 0 && first.Len != t.Len {
+			c.diagnostics = append(c.diagnostics,
+				diag.Warningf(ast.Span(vec), "rows of matrix have different length: %d and %d", first.Len, t.Len))
+			first = UnknownType
+		}
+		elem = join(elem, t)
+	}
+	return VectorOf(elem, len(vec.Elements))
+}
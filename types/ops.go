@@ -0,0 +1,364 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/Richtermnd/ferret/token"
+)
+
+// Rules of operators follow evaluator: outside of strict mode bools are integers
+// in arithmetic and ordering, but never in division, negation and vector scaling.
+// nil problem means that operation may succeed
+
+// problem is operation that always fails at runtime
+type problem struct {
+	message string
+	hint    string
+}
+
+func invalid(format string, args ...any) *problem {
+	return &problem{message: "invalid operation: " + fmt.Sprintf(format, args...)}
+}
+
+func (p *problem) withHint(hint string) *problem {
+	p.hint = hint
+	return p
+}
+
+const strictHint = "bools are not numbers in strict mode"
+
+func infix(tok token.Token, left, right Type, strict bool) (Type, *problem) {
+	if left.Kind == Unknown || right.Kind == Unknown {
+		return UnknownType, nil
+	}
+	if left.Kind == Vector || right.Kind == Vector {
+		return vectorInfix(tok, left, right, strict)
+	}
+	if left.Kind == Builtin || right.Kind == Builtin || left.Kind == Symbolic || right.Kind == Symbolic {
+		return UnknownType, invalid("%s %s %s", left, tok.Literal, right)
+	}
+	lBool, rBool := left.Kind == Bool, right.Kind == Bool
+	switch tok.Type {
+	case token.AND, token.OR:
+		if strict && (!lBool || !rBool) {
+			return UnknownType, invalid("%s %s %s", left, tok.Literal, right).
+				withHint("operands of and, or must be bool in strict mode")
+		}
+		return BoolType, nil
+	case token.EQ, token.NEQ:
+		if strict && lBool != rBool {
+			return UnknownType, invalid("%s %s %s", left, tok.Literal, right).withHint(strictHint)
+		}
+		return BoolType, nil
+	case token.LT, token.LEQ, token.GT, token.GEQ:
+		if strict && (lBool || rBool) {
+			return UnknownType, invalid("%s %s %s", left, tok.Literal, right).withHint(strictHint)
+		}
+		return BoolType, nil
+	case token.ADD, token.SUB, token.MUL, token.REM:
+		if strict && (lBool || rBool) {
+			return UnknownType, invalid("%s %s %s", left, tok.Literal, right).withHint(strictHint)
+		}
+		return arithmetic(left, right), nil
+	case token.DIV:
+		if lBool || rBool {
+			return UnknownType, invalid("%s / %s", left, right)
+		}
+		return arithmetic(left, right), nil
+	case token.POW:
+		if strict && (lBool || rBool) {
+			return UnknownType, invalid("%s ^ %s", left, right).withHint(strictHint)
+		}
+		// like pow, int with negative exponent is float
+		if left.Kind == Float || right.Kind == Float {
+			return FloatType, nil
+		}
+		return NumberType, nil
+	}
+	return UnknownType, nil
+}
+
+// arithmetic return result type of + - * / of numbers, bool is int
+func arithmetic(left, right Type) Type {
+	if left.Kind == Bool {
+		left = IntType
+	}
+	if right.Kind == Bool {
+		right = IntType
+	}
+	switch {
+	case left.Kind == Int && right.Kind == Int:
+		return IntType
+	case left.Kind == Float || right.Kind == Float:
+		return FloatType
+	}
+	return NumberType
+}
+
+func vectorInfix(tok token.Token, left, right Type, strict bool) (Type, *problem) {
+	lVec, rVec := left.Kind == Vector, right.Kind == Vector
+	switch tok.Type {
+	case token.ADD, token.SUB:
+		if !lVec || !rVec {
+			break
+		}
+		if left.Len >= 0 && right.Len >= 0 && left.Len != right.Len {
+			return UnknownType, &problem{message: fmt.Sprintf("shape mismatch: %s %s %s", left, tok.Literal, right)}
+		}
+		elem, p := infix(tok, left.elem(), right.elem(), strict)
+		n := left.Len
+		if n < 0 {
+			n = right.Len
+		}
+		return VectorOf(elem, n), p
+	case token.MUL:
+		switch {
+		case lVec && rVec:
+			return product(left, right, strict)
+		case lVec && right.IsNumeric():
+			elem, p := infix(tok, left.elem(), right, strict)
+			return VectorOf(elem, left.Len), p
+		case rVec && left.IsNumeric():
+			elem, p := infix(tok, left, right.elem(), strict)
+			return VectorOf(elem, right.Len), p
+		}
+	case token.DIV:
+		if lVec && right.IsNumeric() {
+			elem, p := infix(tok, left.elem(), right, strict)
+			return VectorOf(elem, left.Len), p
+		}
+	}
+	return UnknownType, invalid("%s %s %s", left, tok.Literal, right)
+}
+
+var (
+	addToken = token.NoLiteralToken(token.ADD)
+	mulToken = token.NoLiteralToken(token.MUL)
+)
+
+// product of vectors as matrices, vector is a row on the left and a column on the right
+func product(left, right Type, strict bool) (Type, *problem) {
+	if !known(left) || !known(right) {
+		return UnknownType, nil
+	}
+	lMatrix, rMatrix := left.IsMatrix(), right.IsMatrix()
+	// columns of the left one and rows of the right one
+	inner := left.Len
+	if lMatrix {
+		inner = left.elem().Len
+	}
+	if inner >= 0 && right.Len >= 0 && inner != right.Len {
+		return UnknownType, (&problem{message: fmt.Sprintf("shape mismatch: %s * %s", left, right)}).
+			withHint("columns of the left operand must match rows of the right one")
+	}
+
+	lElem, rElem := left.elem(), right.elem()
+	if lMatrix {
+		lElem = lElem.elem()
+	}
+	if rMatrix {
+		rElem = rElem.elem()
+	}
+	product, p := infix(mulToken, lElem, rElem, strict)
+	if p != nil {
+		return UnknownType, p
+	}
+	// sum starts from integer 0
+	elem, p := infix(addToken, IntType, product, strict)
+	switch {
+	case !lMatrix && !rMatrix:
+		return elem, p
+	case lMatrix && !rMatrix:
+		return VectorOf(elem, left.Len), p
+	case !lMatrix && rMatrix:
+		return VectorOf(elem, right.elem().Len), p
+	}
+	return MatrixOf(elem, left.Len, right.elem().Len), p
+}
+
+// known report if it's known whether vector is a matrix:
+// elements are not vectors or vectors of the same length
+func known(t Type) bool {
+	switch t.elem().Kind {
+	case Unknown:
+		return false
+	case Vector:
+		return t.IsMatrix()
+	}
+	return true
+}
+
+func prefix(op string, right Type, strict bool) (Type, *problem) {
+	switch {
+	case right.Kind == Unknown:
+		return UnknownType, nil
+	case op == "-" && right.IsNumeric():
+		return right, nil
+	case op == "-" && right.Kind == Vector:
+		elem, p := prefix(op, right.elem(), strict)
+		return VectorOf(elem, right.Len), p
+	case op == "!" && right.Kind == Bool:
+		return BoolType, nil
+	case op == "!" && right.IsNumeric():
+		if strict {
+			return UnknownType, invalid("!%s", right).withHint("operand of ! must be bool in strict mode")
+		}
+		return BoolType, nil
+	}
+	return UnknownType, invalid("%s%s", op, right)
+}
+
+func postfix(op string, left Type, strict bool) (Type, *problem) {
+	switch {
+	case left.Kind == Unknown:
+		return UnknownType, nil
+	case left.Kind == Bool:
+		if strict {
+			return UnknownType, invalid("%s%s", left, op).withHint(strictHint)
+		}
+		return postfix(op, IntType, strict)
+	case op == "!" && left.IsNumeric():
+		return left, nil
+	case op == "%" && left.IsNumeric():
+		return FloatType, nil
+	}
+	return UnknownType, invalid("%s%s", left, op)
+}
+
+// arity of builtins, -1 is at least one argument, missing ones have one argument
+var arity = map[string]int{
+	"abs": 1,
+	"pow": 2,
+	"min": -1,
+	"max": -1,
+}
+
+func call(function Type, args []Type, strict bool) (Type, *problem) {
+	switch function.Kind {
+	case Unknown:
+		return UnknownType, nil
+	case Builtin:
+		return builtin(function.Name, args, strict)
+	case Function:
+		if len(args) != len(function.Params) {
+			return UnknownType, &problem{message: fmt.Sprintf("%s: expected %d arguments got %d",
+				function.Name, len(function.Params), len(args))}
+		}
+		for i, param := range function.Params {
+			if _, p := assign(function.Name+": argument "+param.Name, args[i], param.Type); p != nil {
+				return UnknownType, p
+			}
+		}
+		return function.result(), nil
+	case Symbolic:
+		// call substitute the variable
+		if len(args) != 1 {
+			return UnknownType, &problem{message: fmt.Sprintf("symbolic: expected 1 arguments got %d", len(args))}
+		}
+		if args[0].IsNumeric() {
+			return NumberType, nil
+		}
+		return UnknownType, nil
+	}
+	return UnknownType, &problem{message: fmt.Sprintf("%s is not callable", function)}
+}
+
+// grad is vector of partial derivatives by every argument: grad(f, [x, y])
+func grad(args []Type) (Type, *problem) {
+	if len(args) != 2 {
+		return UnknownType, &problem{message: fmt.Sprintf("grad: expected 2 arguments got %d", len(args))}
+	}
+	f, point := args[0], args[1]
+	switch {
+	case f.Kind != Unknown && f.Kind != Builtin && f.Kind != Function && f.Kind != Symbolic:
+		return UnknownType, &problem{message: fmt.Sprintf("%s is not callable", f)}
+	case point.Kind == Unknown:
+		return VectorOf(FloatType, -1), nil
+	case point.Kind != Vector:
+		return UnknownType, &problem{message: fmt.Sprintf("invalid argument: grad(%s, %s)", f, point)}
+	case f.Kind == Function && point.Len >= 0 && point.Len != len(f.Params):
+		return UnknownType, &problem{message: fmt.Sprintf("%s: expected %d arguments got %d", f.Name, len(f.Params), point.Len)}
+	}
+	return VectorOf(FloatType, point.Len), nil
+}
+
+// assign check value against annotation of binding name like evaluator.Annotate:
+// int is widened to float, bool is not a number. It return type of bound value
+func assign(name string, value, typ Type) (Type, *problem) {
+	ok := false
+	switch typ.Kind {
+	case Unknown:
+		return value, nil
+	case Int:
+		// number may be int
+		ok = value.Kind == Int || value.Kind == Number
+	case Float:
+		ok = value.IsNumeric()
+	case Bool:
+		ok = value.Kind == Bool
+	case Vector:
+		if value.Kind == Vector {
+			return value, nil
+		}
+	}
+	switch {
+	case value.Kind == Unknown:
+		return typ, nil
+	case !ok:
+		expected := typ.String()
+		if typ.Kind == Vector {
+			expected = "vector"
+		}
+		return UnknownType, &problem{message: fmt.Sprintf("%s: expected %s got %s", name, expected, value)}
+	}
+	return typ, nil
+}
+
+func builtin(name string, args []Type, strict bool) (Type, *problem) {
+	if name == "grad" {
+		return grad(args)
+	}
+	n, ok := arity[name]
+	if !ok {
+		n = 1
+	}
+	switch {
+	case n < 0 && len(args) == 0:
+		return UnknownType, &problem{message: fmt.Sprintf("%s: expected at least 1 argument", name)}
+	case n >= 0 && len(args) != n:
+		return UnknownType, &problem{message: fmt.Sprintf("%s: expected %d arguments got %d", name, n, len(args))}
+	}
+	for _, arg := range args {
+		switch {
+		case arg.Kind == Vector || arg.Kind == Builtin || arg.Kind == Symbolic:
+			return UnknownType, &problem{message: fmt.Sprintf("invalid argument: %s(%s)", name, arg)}
+		case arg.Kind == Bool && (strict || name == "abs"):
+			p := &problem{message: fmt.Sprintf("invalid argument: %s(%s)", name, arg)}
+			if strict {
+				p.hint = strictHint
+			}
+			return UnknownType, p
+		}
+	}
+
+	switch name {
+	case "abs":
+		return args[0], nil
+	case "pow":
+		if args[0].Kind == Float || args[1].Kind == Float {
+			return FloatType, nil
+		}
+		if args[0].Kind == Bool || args[1].Kind == Bool {
+			return FloatType, nil
+		}
+		// int to non-negative int power is int while it fits
+		return NumberType, nil
+	case "min", "max":
+		res := args[0]
+		for _, arg := range args[1:] {
+			res = join(res, arg)
+		}
+		return res, nil
+	}
+	return FloatType, nil
+}
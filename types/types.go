@@ -0,0 +1,238 @@
+// Package types infers static types of ferret programs: int, float, bool, derivatives
+// and vectors or matrices with sizes known from literals.
+// Check reports operations that always fail at runtime before execution,
+// like true * [1, 2] or addition of vectors of different length
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Richtermnd/ferret/object"
+)
+
+type Kind int
+
+const (
+	// Unknown is type that can't be inferred: host bindings, results of errors.
+	// Operations with unknown operands are never reported
+	Unknown Kind = iota
+	Int
+	Float
+	// Number is int or float, like result of pow(2, n)
+	Number
+	Bool
+	Vector
+	Builtin
+	// Function is declared by fn statement
+	Function
+	// Symbolic is expression made by d(expr, x), it can only be called
+	Symbolic
+)
+
+// Type of expression, zero value is unknown type
+type Type struct {
+	Kind Kind
+
+	// Len is number of elements of vector, -1 if it isn't known
+	Len int
+	// Elem is type of vector elements, matrix is a vector of vectors
+	Elem *Type
+
+	// Name of builtin function or function declared by fn
+	Name string
+
+	// Params and Result of function, result is unknown without annotation
+	// if it can't be inferred from body
+	Params []Param
+	Result *Type
+}
+
+// Param is parameter of function, type is unknown without annotation
+type Param struct {
+	Name string
+	Type Type
+}
+
+var (
+	UnknownType  = Type{Kind: Unknown}
+	IntType      = Type{Kind: Int}
+	FloatType    = Type{Kind: Float}
+	NumberType   = Type{Kind: Number}
+	BoolType     = Type{Kind: Bool}
+	SymbolicType = Type{Kind: Symbolic}
+)
+
+// VectorOf return type of vector with n elements, n is -1 if it's unknown
+func VectorOf(elem Type, n int) Type {
+	return Type{Kind: Vector, Len: n, Elem: &elem}
+}
+
+// MatrixOf return type of matrix with n rows and m columns
+func MatrixOf(elem Type, n, m int) Type {
+	return VectorOf(VectorOf(elem, m), n)
+}
+
+func BuiltinOf(name string) Type {
+	return Type{Kind: Builtin, Name: name}
+}
+
+func FunctionOf(name string, params []Param, result Type) Type {
+	return Type{Kind: Function, Name: name, Params: params, Result: &result}
+}
+
+// Of return type of value, like bindings of interpreter passed in Options.Globals
+func Of(obj object.Object) Type {
+	switch obj := obj.(type) {
+	case *object.Integer, *object.BigInt:
+		return IntType
+	case *object.Float, *object.Dual:
+		return FloatType
+	case *object.Bool:
+		return BoolType
+	case *object.Vector:
+		if len(obj.Elements) == 0 {
+			return VectorOf(UnknownType, 0)
+		}
+		elem := Of(obj.Elements[0])
+		for _, el := range obj.Elements[1:] {
+			elem = join(elem, Of(el))
+		}
+		return VectorOf(elem, len(obj.Elements))
+	case *object.Builtin:
+		return BuiltinOf(obj.Name)
+	case *object.Symbolic:
+		return SymbolicType
+	case *object.Function:
+		fn := obj.Definition
+		params := make([]Param, len(fn.Parameters))
+		for i, param := range fn.Parameters {
+			params[i] = Param{Name: param.Name.Value}
+			if param.Type != nil {
+				params[i].Type = annotation(param.Type.Name)
+			}
+		}
+		result := UnknownType
+		if fn.ReturnType != nil {
+			result = annotation(fn.ReturnType.Name)
+		}
+		return FunctionOf(fn.Name.Value, params, result)
+	}
+	return UnknownType
+}
+
+// annotation return type of annotation like float in let rate: float = 0.05,
+// vector is a vector of unknown length
+func annotation(name string) Type {
+	switch name {
+	case "int":
+		return IntType
+	case "float":
+		return FloatType
+	case "bool":
+		return BoolType
+	case "vector":
+		return VectorOf(UnknownType, -1)
+	}
+	return UnknownType
+}
+
+// String return type as it's written in messages: int, [3]float, [2][3]int
+func (t Type) String() string {
+	switch t.Kind {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Number:
+		return "number"
+	case Bool:
+		return "bool"
+	case Vector:
+		var sb strings.Builder
+		for ; t.Kind == Vector; t = t.elem() {
+			sb.WriteString("[")
+			if t.Len >= 0 {
+				sb.WriteString(fmt.Sprint(t.Len))
+			}
+			sb.WriteString("]")
+		}
+		if t.Kind == Unknown {
+			sb.WriteString("?")
+		} else {
+			sb.WriteString(t.String())
+		}
+		return sb.String()
+	case Builtin:
+		return "builtin " + t.Name
+	case Function:
+		var sb strings.Builder
+		sb.WriteString("fn " + t.Name + "(")
+		for i, param := range t.Params {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(param.Type.String())
+		}
+		sb.WriteString(") " + t.result().String())
+		return sb.String()
+	case Symbolic:
+		return "symbolic"
+	}
+	return "?"
+}
+
+// elem return type of elements of vector
+func (t Type) elem() Type {
+	if t.Elem == nil {
+		return UnknownType
+	}
+	return *t.Elem
+}
+
+// result return type of function result
+func (t Type) result() Type {
+	if t.Result == nil {
+		return UnknownType
+	}
+	return *t.Result
+}
+
+// IsNumeric report if t is int, float or number
+func (t Type) IsNumeric() bool {
+	return t.Kind == Int || t.Kind == Float || t.Kind == Number
+}
+
+// IsMatrix report if t is a vector of vectors of known length:
+// rows of matrix have the same length
+func (t Type) IsMatrix() bool {
+	return t.Kind == Vector && t.Len != 0 && t.elem().Kind == Vector && t.elem().Len >= 0
+}
+
+// Equal report if types are the same
+func (t Type) Equal(t2 Type) bool {
+	if t.Kind != t2.Kind || t.Len != t2.Len || t.Name != t2.Name {
+		return false
+	}
+	if t.Kind == Vector {
+		return t.elem().Equal(t2.elem())
+	}
+	return true
+}
+
+// join return type that covers both types, elements of vector literal [1, 2.5] are numbers
+func join(a, b Type) Type {
+	switch {
+	case a.Equal(b):
+		return a
+	case a.IsNumeric() && b.IsNumeric():
+		return NumberType
+	case a.Kind == Vector && b.Kind == Vector:
+		n := a.Len
+		if a.Len != b.Len {
+			n = -1
+		}
+		return VectorOf(join(a.elem(), b.elem()), n)
+	}
+	return UnknownType
+}
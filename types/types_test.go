@@ -0,0 +1,201 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/diag"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/types"
+)
+
+func parse(t *testing.T, source string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.Parse()
+	if p.HasErrors() {
+		t.Fatal(p.Errors())
+	}
+	return program
+}
+
+// last return the last expression of program
+func last(program *ast.Program) ast.Expression {
+	stmt := program.Statements[len(program.Statements)-1]
+	return stmt.(*ast.ExpressionStatement).Expr
+}
+
+func TestInfer(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{source: "1 + 2 * 3", expected: "int"},
+		{source: "1 + 2.5", expected: "float"},
+		{source: "7 / 2", expected: "int"},
+		{source: "7 % 2", expected: "int"},
+		{source: "7 % 2.5", expected: "float"},
+		{source: "5 + true", expected: "int"},
+		{source: "0 < 1 <= 2", expected: "bool"},
+		{source: "1 and 0", expected: "bool"},
+		{source: "5!", expected: "int"},
+		{source: "15%", expected: "float"},
+		{source: "sqrt(4)", expected: "float"},
+		{source: "abs(-2)", expected: "int"},
+		{source: "pow(2, 3)", expected: "number"},
+		{source: "2 ^ 3", expected: "number"},
+		{source: "2 ^ 0.5", expected: "float"},
+		{source: "d(t^2, t)", expected: "symbolic"},
+		{source: "d(t^2, t)(3)", expected: "number"},
+		{source: "let f = d(t^3, t)\nd(f, t)", expected: "symbolic"},
+		{source: "grad(d(t^2, t), [1])", expected: "[1]float"},
+		{source: "fn f(a, b) { a * b }\ngrad(f, [1, 2])", expected: "[2]float"},
+		{source: "min(1, 2.5)", expected: "number"},
+		{source: "sqrt", expected: "builtin sqrt"},
+		{source: "let a = 2\nlet b = a * 1.5\nb", expected: "float"},
+		{source: "let a = 2\n{ let a = true }\na", expected: "int"},
+		{source: "[1, 2, 3]", expected: "[3]int"},
+		{source: "[1, 2.5]", expected: "[2]number"},
+		{source: "[]", expected: "[0]?"},
+		{source: "[[1, 2, 3], [4, 5, 6]]", expected: "[2][3]int"},
+		{source: "let v = [1, 2]\n2.0 * v", expected: "[2]float"},
+		{source: "-[1, 2] / 2", expected: "[2]int"},
+		{source: "[1, 2] + [3, 4]", expected: "[2]int"},
+		{source: "[1, 2] * [3, 4]", expected: "int"},
+		{source: "[[1, 2], [3, 4], [5, 6]] * [1.5, 1]", expected: "[3]number"},
+		{source: "[1, 1, 1] * [[1, 2], [3, 4], [5, 6]]", expected: "[2]int"},
+		{source: "[[1, 2, 3]] * [[1], [2], [3]]", expected: "[1][1]int"},
+		{source: "x * 2", expected: "?"},
+		{source: "let r: float = 2\nr", expected: "float"},
+		{source: "let n: int = pow(2, 3)\nn", expected: "int"},
+		{source: "let v: vector = [1, 2]\nv", expected: "[2]int"},
+		{source: "fn area(r: float) -> float { 3 * r * r }\narea", expected: "fn area(float) float"},
+		{source: "fn twice(x: int) { 2 * x }\ntwice(1)", expected: "int"},
+		{source: "fn f(x) { x * 2 }\nf(1)", expected: "?"},
+		{source: "let k = 2\nfn f(x: int) { k * x }\nf(1)", expected: "?"},
+		{source: "import \"stats.fe\" as s\ns.mean([1, 2])", expected: "?"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			program := parse(t, tt.source)
+			opts := types.Options{Globals: map[string]types.Type{"x": types.UnknownType}}
+			info, diagnostics := types.Check(program, opts)
+			if len(diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostics: %v", diagnostics)
+			}
+			if res := info.TypeOf(last(program)).String(); res != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	testCases := []struct {
+		source   string
+		strict   bool
+		expected string
+	}{
+		{source: "true * [1, 2]", expected: "1:1: invalid operation: bool * [2]int"},
+		{source: "let v = [1, 2]\nlet w = [1, 2, 3]\nv + w", expected: "3:1: shape mismatch: [2]int + [3]int"},
+		{source: "[[1, 2, 3], [4, 5, 6]] * [[1, 2], [3, 4]]", expected: "1:1: shape mismatch: [2][3]int * [2][2]int"},
+		{source: "[1, 2] + 1", expected: "1:1: invalid operation: [2]int + int"},
+		{source: "-true", expected: "1:1: invalid operation: -bool"},
+		{source: "2 ^ true", strict: true, expected: "1:1: invalid operation: int ^ bool"},
+		{source: "let d = 1\nd(2, 3)", expected: "2:1: int is not callable"},
+		{source: "let a = 1 > 0\na / 2", expected: "2:1: invalid operation: bool / int"},
+		{source: "[1] < 2", expected: "1:1: invalid operation: [1]int < int"},
+		{source: "sqrt([1, 2])", expected: "1:1: invalid argument: sqrt([2]int)"},
+		{source: "sqrt(1, 2)", expected: "1:1: sqrt: expected 1 arguments got 2"},
+		{source: "let a = 1\na(2)", expected: "2:1: int is not callable"},
+		{source: "y + 1", expected: "1:1: undefined: y"},
+		{source: "5 + true", strict: true, expected: "1:1: invalid operation: int + bool"},
+		{source: "{ #strict\n1 and 2 }", expected: "2:1: invalid operation: int and int"},
+		{source: "[[1, 2], [3]]", expected: "1:1: warning: rows of matrix have different length: 2 and 1"},
+		{source: "let rate: float = 1 > 0", expected: "1:19: rate: expected float got bool"},
+		{source: "let n: int = 2.5", expected: "1:14: n: expected int got float"},
+		{source: "let v: vector = 1", expected: "1:17: v: expected vector got int"},
+		{source: "fn area(r: float) { r }\narea(true)", expected: "2:1: area: argument r: expected float got bool"},
+		{source: "fn area(r) { r }\narea(1, 2)", expected: "2:1: area: expected 1 arguments got 2"},
+		{source: "fn half(x: int) -> int { x / 2.0 }\nhalf(1)", expected: "1:26: half: result: expected int got float"},
+		{source: "fn f(x: bool) { x * [1] }\nf(true)", expected: "1:17: invalid operation: bool * [1]int"},
+		{source: "fn f(a, b) { a * b }\ngrad(f, [1])", expected: "2:1: f: expected 2 arguments got 1"},
+		{source: "grad(2, [1])", expected: "1:1: int is not callable"},
+		{source: "d(x^2, x) + 1", expected: "1:1: invalid operation: symbolic + int"},
+		{source: "-d(x^2, x)", expected: "1:1: invalid operation: -symbolic"},
+		{source: "d(x^2, x) < 1 < 2", expected: "1:1: invalid operation: symbolic < int"},
+		{source: "sin(d(x^2, x))", expected: "1:1: invalid argument: sin(symbolic)"},
+		{source: "d(x^2, x)(1, 2)", expected: "1:1: symbolic: expected 1 arguments got 2"},
+		{source: "let f: float = d(x^2, x)", expected: "1:16: f: expected float got symbolic"},
+		{source: "(true * [1]).x", expected: "1:2: invalid operation: bool * [1]int"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			program := parse(t, tt.source)
+			_, diagnostics := types.Check(program, types.Options{Strict: tt.strict})
+			if len(diagnostics) != 1 {
+				t.Fatalf("expected one diagnostic got %v", diagnostics)
+			}
+			if res := diagnostics[0].Error(); res != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, res)
+			}
+			if diagnostics[0].Severity != diag.Error {
+				return
+			}
+			// errors are real: evaluation fails
+			env := object.NewEnv()
+			env.SetStrict(tt.strict)
+			if res := evaluator.Eval(env, program); res == nil || !object.IsError(res) {
+				t.Errorf("evaluation didn't fail: %v", res)
+			}
+		})
+	}
+}
+
+func TestNoCascade(t *testing.T) {
+	program := parse(t, "let a = true * [1]\nlet b = a + 1\nb * 2")
+	_, diagnostics := types.Check(program, types.Options{})
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "bool * [1]int") {
+		t.Errorf("expected only the first error got %v", diagnostics)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	program := parse(t, "m * v")
+	opts := types.Options{Globals: map[string]types.Type{
+		"m": types.MatrixOf(types.FloatType, 2, 3),
+		"v": types.VectorOf(types.IntType, 2),
+	}}
+	_, diagnostics := types.Check(program, opts)
+	if len(diagnostics) != 1 || diagnostics[0].Message != "shape mismatch: [2][3]float * [2]int" {
+		t.Errorf("expected shape mismatch got %v", diagnostics)
+	}
+}
+
+func TestOf(t *testing.T) {
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		{source: "2", expected: "int"},
+		{source: "30!", expected: "int"},
+		{source: "2.5", expected: "float"},
+		{source: "1 < 2", expected: "bool"},
+		{source: "[[1, 2], [3, 4.5]]", expected: "[2][2]number"},
+		{source: "sin", expected: "builtin sin"},
+		{source: "d(x^2, x)", expected: "symbolic"},
+		{source: "fn f(x: float, n) -> float { x * n }\nf", expected: "fn f(float, ?) float"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			res := evaluator.Eval(object.NewEnv(), parse(t, tt.source))
+			if got := types.Of(res).String(); got != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package ferret
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/object"
+)
+
+// Value is a result of evaluation, zero Value is a result of statement without value (let)
+type Value struct {
+	obj object.Object
+}
+
+// Object return underlying object, nil for zero Value
+func (v Value) Object() object.Object {
+	return v.obj
+}
+
+func (v Value) IsNil() bool {
+	return v.obj == nil
+}
+
+// Type return ferret type name like INTEGER or FLOAT, empty for zero Value
+func (v Value) Type() string {
+	if v.obj == nil {
+		return ""
+	}
+	return string(v.obj.Type())
+}
+
+// Interface return value as Go value: int64, float64, bool, *big.Int or nil
+func (v Value) Interface() any {
+	switch obj := v.obj.(type) {
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.Bool:
+		return obj.Value
+	case *object.BigInt:
+		return new(big.Int).Set(obj.Value)
+	}
+	return nil
+}
+
+// Float return numeric value as float64, ok is false for non-numbers
+func (v Value) Float() (float64, bool) {
+	if _, ok := v.obj.(*object.Bool); ok {
+		return 0, false
+	}
+	return object.AsNative(v.obj)
+}
+
+// Int return integer value, ok is false for non-integers and integers that don't fit in int64
+func (v Value) Int() (int64, bool) {
+	obj, ok := v.obj.(*object.Integer)
+	if !ok {
+		return 0, false
+	}
+	return obj.Value, true
+}
+
+func (v Value) Bool() (bool, bool) {
+	obj, ok := v.obj.(*object.Bool)
+	if !ok {
+		return false, false
+	}
+	return obj.Value, true
+}
+
+// String return value as ferret prints it
+func (v Value) String() string {
+	if v.obj == nil {
+		return "<nil>"
+	}
+	return v.obj.Inspect()
+}
+
+// ToObject convert Go value to ferret object.
+// Supported types are integers, floats, bools, slices and arrays of them (vectors),
+// *big.Int, Value and object.Object
+func ToObject(v any) (object.Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("nil value")
+	case object.Object:
+		return v, nil
+	case Value:
+		if v.obj == nil {
+			return nil, fmt.Errorf("nil value")
+		}
+		return v.obj, nil
+	case *big.Int:
+		if v == nil {
+			return nil, fmt.Errorf("nil *big.Int")
+		}
+		return object.NewBigInt(new(big.Int).Set(v)), nil
+	}
+	return toObject(reflect.ValueOf(v))
+}
+
+func toObject(v reflect.Value) (object.Object, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return &object.BigInt{Value: new(big.Int).SetUint64(v.Uint())}, nil
+		}
+		return &object.Integer{Value: int64(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+	case reflect.Slice, reflect.Array:
+		vec := &object.Vector{Elements: make([]object.Object, v.Len())}
+		for i := range vec.Elements {
+			el, err := toObject(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			vec.Elements[i] = el
+		}
+		return vec, nil
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			break
+		}
+		switch x := v.Interface().(type) {
+		case object.Object, *big.Int:
+			return ToObject(x)
+		}
+		if v.Kind() == reflect.Interface {
+			return toObject(v.Elem())
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
@@ -0,0 +1,482 @@
+// Package vm execute bytecode produced by compiler.
+// Results match evaluator: operations on objects are shared with it,
+// numbers have fast paths that skip interface dispatch
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Richtermnd/ferret/ast"
+	"github.com/Richtermnd/ferret/compiler"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/token"
+)
+
+// StackSize is the maximum depth of stack
+const StackSize = 2048
+
+type VM struct {
+	constants    []object.Object
+	names        []string
+	instructions compiler.Instructions
+
+	stack []object.Object // grows up to StackSize, top is the last element
+
+	// scopes is stack of environments, the last one is current
+	scopes []*object.Environment
+	strict bool
+
+	// last is result of the last statement
+	last object.Object
+
+	// modules load imports relative to file, imports are errors without them
+	modules *evaluator.Modules
+	file    string
+
+	// outer is depth of callers and calls is nesting of function calls,
+	// vm of function body continues them
+	outer int
+	calls int
+}
+
+// New create VM that run bytecode in env, names are bound there
+// or in sub environment of env if it's frozen
+func New(bytecode *compiler.Bytecode, env *object.Environment) *VM {
+	if env.Frozen() {
+		env = env.SubEnv()
+	}
+	return &VM{
+		constants:    bytecode.Constants,
+		names:        bytecode.Names,
+		instructions: bytecode.Instructions,
+		stack:        make([]object.Object, 0, 16),
+		scopes:       []*object.Environment{env},
+		strict:       env.Strict(),
+	}
+}
+
+// SetModules enable imports, paths are relative to file like in Modules.EvalContext
+func (vm *VM) SetModules(modules *evaluator.Modules, file string) {
+	vm.modules = modules
+	vm.file = file
+}
+
+// Result return result of the last statement like evaluator.Eval, nil if it has no value
+func (vm *VM) Result() object.Object {
+	return vm.last
+}
+
+// infixTokens is operator token of binary opcodes, it is used in error messages
+var infixTokens = [...]token.Token{
+	compiler.OpAdd:          token.NoLiteralToken(token.ADD),
+	compiler.OpSub:          token.NoLiteralToken(token.SUB),
+	compiler.OpMul:          token.NoLiteralToken(token.MUL),
+	compiler.OpDiv:          token.NoLiteralToken(token.DIV),
+	compiler.OpRem:          token.NoLiteralToken(token.REM),
+	compiler.OpPow:          token.NoLiteralToken(token.POW),
+	compiler.OpEqual:        token.NoLiteralToken(token.EQ),
+	compiler.OpNotEqual:     token.NoLiteralToken(token.NEQ),
+	compiler.OpLess:         token.NoLiteralToken(token.LT),
+	compiler.OpLessEqual:    token.NoLiteralToken(token.LEQ),
+	compiler.OpGreater:      token.NoLiteralToken(token.GT),
+	compiler.OpGreaterEqual: token.NoLiteralToken(token.GEQ),
+	compiler.OpAnd:          token.NoLiteralToken(token.AND),
+	compiler.OpOr:           token.NoLiteralToken(token.OR),
+}
+
+// prefixOperators is operator of unary opcodes
+var prefixOperators = [...]string{
+	compiler.OpMinus: "-",
+	compiler.OpNot:   "!",
+}
+
+// Run execute bytecode, errors of ferret program are values and returned by Result,
+// error is returned only for malformed bytecode
+func (vm *VM) Run() error {
+	return vm.RunContext(context.Background(), evaluator.Limits{})
+}
+
+// RunContext is Run that stops when ctx is done or limits are exceeded,
+// then Result is error with LIMIT_ERR type. Steps are executed instructions
+// and depth is size of stack with nested scopes, bodies of called functions included
+func (vm *VM) RunContext(ctx context.Context, limits evaluator.Limits) error {
+	_, err := vm.run(ctx, limits, 0)
+	return err
+}
+
+// run execute instructions counting steps from steps of caller,
+// it return steps counted by the end
+func (vm *VM) run(ctx context.Context, limits evaluator.Limits, steps int) (int, error) {
+	done := ctx.Done()
+	limited := done != nil || limits.MaxSteps > 0 || limits.MaxDepth > 0
+	ins := vm.instructions
+	for ip := 0; ip < len(ins); ip++ {
+		if limited {
+			steps++
+			if err := vm.checkLimits(ctx, limits, steps); err != nil {
+				vm.last = err
+				return steps, nil
+			}
+		}
+		op := compiler.Opcode(ins[ip])
+		switch op {
+		case compiler.OpConstant:
+			index := compiler.ReadUint16(ins[ip+1:])
+			ip += 2
+			if err := vm.push(vm.constants[index]); err != nil {
+				return steps, err
+			}
+
+		case compiler.OpTrue:
+			if err := vm.push(evaluator.TRUE); err != nil {
+				return steps, err
+			}
+
+		case compiler.OpFalse:
+			if err := vm.push(evaluator.FALSE); err != nil {
+				return steps, err
+			}
+
+		case compiler.OpNull:
+			if err := vm.push(nil); err != nil {
+				return steps, err
+			}
+
+		case compiler.OpPop:
+			// program stops on the first error
+			vm.last = vm.pop()
+			if vm.last != nil && object.IsError(vm.last) {
+				return steps, nil
+			}
+
+		case compiler.OpGetName:
+			index := compiler.ReadUint16(ins[ip+1:])
+			ip += 2
+			if err := vm.push(vm.lookup(vm.names[index])); err != nil {
+				return steps, err
+			}
+
+		case compiler.OpSetName:
+			index := compiler.ReadUint16(ins[ip+1:])
+			ip += 2
+			value := vm.pop()
+			if object.IsError(value) {
+				vm.last = value
+				return steps, nil
+			}
+			vm.env().Set(vm.names[index], value)
+			vm.last = nil
+
+		case compiler.OpAnnotate:
+			name := compiler.ReadUint16(ins[ip+1:])
+			typ := compiler.ReadUint16(ins[ip+3:])
+			ip += 4
+			vm.push(evaluator.Annotate(vm.names[name], vm.names[typ], vm.pop()))
+
+		case compiler.OpFunction:
+			index := compiler.ReadUint16(ins[ip+1:])
+			ip += 2
+			fn := vm.constants[index].(*object.Function)
+			if err := vm.push(&object.Function{Definition: fn.Definition, Env: vm.env(), Code: fn.Code}); err != nil {
+				return steps, err
+			}
+
+		case compiler.OpImport:
+			index := compiler.ReadUint16(ins[ip+1:])
+			ip += 2
+			path := vm.names[index]
+			var res object.Object
+			if vm.modules == nil {
+				res = object.NewError(object.IMPORT_ERR, "%q: imports are not supported here", path)
+			} else {
+				res, steps = vm.modules.Import(ctx, vm.file, path, limits, steps, vm.depth())
+				if object.IsLimitError(res) {
+					vm.last = res
+					return steps, nil
+				}
+			}
+			if err := vm.push(res); err != nil {
+				return steps, err
+			}
+
+		case compiler.OpSelect:
+			index := compiler.ReadUint16(ins[ip+1:])
+			ip += 2
+			vm.push(evaluator.Select(vm.pop(), vm.names[index]))
+
+		case compiler.OpEnterScope:
+			vm.scopes = append(vm.scopes, vm.env().SubEnv())
+			vm.last = nil
+
+		case compiler.OpLeaveScope:
+			if len(vm.scopes) == 1 {
+				return steps, fmt.Errorf("leave of global scope at %d", ip)
+			}
+			vm.scopes = vm.scopes[:len(vm.scopes)-1]
+			vm.strict = vm.env().Strict()
+
+		case compiler.OpStrict:
+			vm.env().SetStrict(true)
+			vm.strict = true
+			vm.last = nil
+
+		case compiler.OpAdd, compiler.OpSub, compiler.OpMul, compiler.OpDiv, compiler.OpRem, compiler.OpPow,
+			compiler.OpEqual, compiler.OpNotEqual, compiler.OpLess, compiler.OpLessEqual,
+			compiler.OpGreater, compiler.OpGreaterEqual, compiler.OpAnd, compiler.OpOr:
+			right := vm.pop()
+			left := vm.pop()
+			res := vm.infix(op, left, right, limits)
+			if object.IsLimitError(res) {
+				vm.last = res
+				return steps, nil
+			}
+			vm.push(res)
+
+		case compiler.OpMinus, compiler.OpNot:
+			res := evaluator.PrefixLimits(prefixOperators[op], vm.pop(), vm.strict, limits)
+			if object.IsLimitError(res) {
+				vm.last = res
+				return steps, nil
+			}
+			vm.push(res)
+
+		case compiler.OpFactorial:
+			res := evaluator.PostfixContext(ctx, "!", vm.pop(), vm.strict, limits)
+			if object.IsLimitError(res) {
+				vm.last = res
+				return steps, nil
+			}
+			vm.push(res)
+
+		case compiler.OpPercent:
+			vm.push(evaluator.Postfix("%", vm.pop(), vm.strict))
+
+		case compiler.OpCall:
+			argc := int(ins[ip+1])
+			ip++
+			sp := len(vm.stack)
+			args := make([]object.Object, argc)
+			copy(args, vm.stack[sp-argc:])
+			function := vm.stack[sp-argc-1]
+			clear(vm.stack[sp-argc-1:])
+			vm.stack = vm.stack[:sp-argc-1]
+			var res object.Object
+			if fn, ok := function.(*object.Function); ok && fn.Code != nil {
+				var err error
+				if res, steps, err = vm.call(ctx, limits, steps, fn, args); err != nil {
+					return steps, err
+				}
+			} else {
+				// functions declared by evaluator, symbolics and grad are evaluated by it,
+				// it continues counting of limits
+				res, steps = evaluator.CallContext(ctx, function, args, vm.strict, limits, steps, vm.depth())
+			}
+			if object.IsLimitError(res) {
+				vm.last = res
+				return steps, nil
+			}
+			if err := evaluator.CheckAlloc(res, limits); err != nil {
+				vm.last = err
+				return steps, nil
+			}
+			vm.push(res)
+
+		case compiler.OpDerive:
+			call := vm.constants[compiler.ReadUint16(ins[ip+1:])].(*object.Symbolic).Expr.(*ast.CallExpression)
+			end := int(compiler.ReadUint16(ins[ip+3:]))
+			ip += 4
+			if evaluator.IsDerive(vm.env(), call) {
+				if err := vm.push(evaluator.Derive(vm.env(), call)); err != nil {
+					return steps, err
+				}
+				ip = end - 1
+			}
+
+		case compiler.OpVector:
+			n := int(compiler.ReadUint16(ins[ip+1:]))
+			ip += 2
+			sp := len(vm.stack)
+			elements := make([]object.Object, n)
+			copy(elements, vm.stack[sp-n:])
+			clear(vm.stack[sp-n:])
+			vm.stack = vm.stack[:sp-n]
+			var res object.Object = &object.Vector{Elements: elements}
+			for _, el := range elements {
+				if object.IsError(el) {
+					res = el
+					break
+				}
+			}
+			if err := evaluator.CheckAlloc(res, limits); err != nil {
+				vm.last = err
+				return steps, nil
+			}
+			vm.push(res)
+
+		case compiler.OpChain:
+			tok := token.NoLiteralToken(token.TokenType(ins[ip+1]))
+			end := int(compiler.ReadUint16(ins[ip+2:]))
+			ip += 3
+			right := vm.pop()
+			left := vm.pop()
+			res := evaluator.Infix(tok, left, right, vm.strict)
+			switch {
+			case object.IsError(res):
+				vm.push(res)
+				ip = end - 1
+			case !evaluator.IsTrue(res):
+				vm.push(evaluator.FALSE)
+				ip = end - 1
+			default:
+				vm.push(right)
+			}
+
+		case compiler.OpChainEnd:
+			tok := token.NoLiteralToken(token.TokenType(ins[ip+1]))
+			ip++
+			right := vm.pop()
+			left := vm.pop()
+			res := evaluator.Infix(tok, left, right, vm.strict)
+			switch {
+			case object.IsError(res):
+				vm.push(res)
+			case evaluator.IsTrue(res):
+				vm.push(evaluator.TRUE)
+			default:
+				vm.push(evaluator.FALSE)
+			}
+
+		default:
+			return steps, fmt.Errorf("unknown opcode %d at %d", op, ip)
+		}
+	}
+	return steps, nil
+}
+
+func (vm *VM) checkLimits(ctx context.Context, limits evaluator.Limits, steps int) object.Object {
+	select {
+	case <-ctx.Done():
+		return object.NewError(object.LIMIT_ERR, "%v", ctx.Err())
+	default:
+	}
+	if limits.MaxSteps > 0 && steps > limits.MaxSteps {
+		return object.NewError(object.LIMIT_ERR, "more than %d steps", limits.MaxSteps)
+	}
+	if depth := vm.depth(); limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return object.NewError(object.LIMIT_ERR, "nesting deeper than %d", limits.MaxDepth)
+	}
+	return nil
+}
+
+// depth is size of stack with nested scopes of vm and its callers
+func (vm *VM) depth() int {
+	return vm.outer + len(vm.stack) + len(vm.scopes)
+}
+
+// call run compiled body of fn in its own vm like evaluator applies function,
+// counting of steps and depth continues there
+func (vm *VM) call(ctx context.Context, limits evaluator.Limits, steps int, fn *object.Function, args []object.Object) (object.Object, int, error) {
+	env, err := evaluator.Bind(fn, args, vm.calls)
+	if err != nil {
+		return err, steps, nil
+	}
+	body := &VM{
+		constants:    fn.Code.Constants,
+		names:        fn.Code.Names,
+		instructions: fn.Code.Instructions,
+		stack:        make([]object.Object, 0, 16),
+		scopes:       []*object.Environment{env},
+		strict:       env.Strict(),
+		modules:      vm.modules,
+		file:         vm.file,
+		outer:        vm.depth(),
+		calls:        vm.calls + 1,
+	}
+	steps, runErr := body.run(ctx, limits, steps)
+	if runErr != nil {
+		return nil, steps, runErr
+	}
+	if object.IsLimitError(body.last) {
+		return body.last, steps, nil
+	}
+	return evaluator.Result(fn, body.last), steps, nil
+}
+
+func (vm *VM) env() *object.Environment {
+	return vm.scopes[len(vm.scopes)-1]
+}
+
+func (vm *VM) lookup(name string) object.Object {
+	if obj, ok := vm.env().Get(name); ok {
+		return obj
+	}
+	if builtin, ok := object.LookupBuiltin(name); ok {
+		return builtin
+	}
+	return object.NewError(object.NOT_FOUND_ERR, "%s", name)
+}
+
+// infix compute binary operation, integers and floats don't go through evaluator
+func (vm *VM) infix(op compiler.Opcode, left, right object.Object, limits evaluator.Limits) object.Object {
+	switch l := left.(type) {
+	case *object.Integer:
+		if r, ok := right.(*object.Integer); ok {
+			switch op {
+			case compiler.OpAdd:
+				return integer(l.Value + r.Value)
+			case compiler.OpSub:
+				return integer(l.Value - r.Value)
+			case compiler.OpMul:
+				return integer(l.Value * r.Value)
+			}
+		}
+	case *object.Float:
+		if r, ok := right.(*object.Float); ok {
+			switch op {
+			case compiler.OpAdd:
+				return &object.Float{Value: l.Value + r.Value}
+			case compiler.OpSub:
+				return &object.Float{Value: l.Value - r.Value}
+			case compiler.OpMul:
+				return &object.Float{Value: l.Value * r.Value}
+			case compiler.OpDiv:
+				return &object.Float{Value: l.Value / r.Value}
+			}
+		}
+	}
+	return evaluator.InfixLimits(infixTokens[op], left, right, vm.strict, limits)
+}
+
+// smallIntegers are shared to save allocations, objects are immutable
+var smallIntegers [256]*object.Integer
+
+func init() {
+	for i := range smallIntegers {
+		smallIntegers[i] = &object.Integer{Value: int64(i)}
+	}
+}
+
+func integer(v int64) *object.Integer {
+	if 0 <= v && v < int64(len(smallIntegers)) {
+		return smallIntegers[v]
+	}
+	return &object.Integer{Value: v}
+}
+
+func (vm *VM) push(obj object.Object) error {
+	if len(vm.stack) >= StackSize {
+		return fmt.Errorf("stack overflow")
+	}
+	vm.stack = append(vm.stack, obj)
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	top := len(vm.stack) - 1
+	obj := vm.stack[top]
+	vm.stack[top] = nil
+	vm.stack = vm.stack[:top]
+	return obj
+}
@@ -0,0 +1,171 @@
+package vm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Richtermnd/ferret/compiler"
+	"github.com/Richtermnd/ferret/evaluator"
+	"github.com/Richtermnd/ferret/lexer"
+	"github.com/Richtermnd/ferret/object"
+	"github.com/Richtermnd/ferret/parser"
+	"github.com/Richtermnd/ferret/vm"
+)
+
+// Results of programs are checked against evaluator in evaluator tests,
+// these tests cover environment handling
+
+func compile(t testing.TB, source string) *compiler.Bytecode {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.Parse()
+	if p.HasErrors() {
+		t.Fatal(p.Errors())
+	}
+	bytecode, err := compiler.Compile(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytecode
+}
+
+func run(t testing.TB, bytecode *compiler.Bytecode, env *object.Environment) object.Object {
+	t.Helper()
+	machine := vm.New(bytecode, env)
+	if err := machine.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return machine.Result()
+}
+
+func TestBindings(t *testing.T) {
+	bytecode := compile(t, "let b = a * 2\n{ let a = 1 }\nb + a")
+	for _, a := range []int64{1, 5, -3} {
+		env := object.NewEnv()
+		env.Set("a", &object.Integer{Value: a})
+		res, ok := run(t, bytecode, env).(*object.Integer)
+		if !ok || res.Value != 3*a {
+			t.Errorf("a = %d: expected %d got %v", a, 3*a, res)
+		}
+		if b, _ := env.Get("b"); b == nil || b.Inspect() != (&object.Integer{Value: 2 * a}).Inspect() {
+			t.Errorf("a = %d: b is not bound in env: %v", a, b)
+		}
+	}
+}
+
+func TestStrictEnv(t *testing.T) {
+	bytecode := compile(t, "1 + true")
+	env := object.NewEnv()
+	env.SetStrict(true)
+	if res := run(t, bytecode, env); !object.IsError(res) {
+		t.Errorf("expected error in strict env got %s", res.Inspect())
+	}
+	if res := run(t, bytecode, object.NewEnv()); res.Inspect() != "2" {
+		t.Errorf("expected 2 got %s", res.Inspect())
+	}
+}
+
+func TestEmptyProgram(t *testing.T) {
+	if res := run(t, compile(t, ""), object.NewEnv()); res != nil {
+		t.Errorf("expected nil got %s", res.Inspect())
+	}
+}
+
+const benchSource = "let x = 0.5\nlet y = 0.25\nx*x + y*y <= 1.0\n(1 + 2) * (3 + 4) - 5 * 6 + 7 * (8 - 9) * 10 + 1.5 * 2.5 - 3.5 / 4.5"
+
+func BenchmarkEvaluator(b *testing.B) {
+	program := parser.New(lexer.New(benchSource)).Parse()
+	for b.Loop() {
+		evaluator.Eval(object.NewEnv(), program)
+	}
+}
+
+func BenchmarkVM(b *testing.B) {
+	bytecode := compile(b, benchSource)
+	for b.Loop() {
+		vm.New(bytecode, object.NewEnv()).Run()
+	}
+}
+
+// TestCalls check that functions declared by compiled program run as bytecode
+// and the ones declared by evaluator are evaluated by it
+func TestCalls(t *testing.T) {
+	env := object.NewEnv()
+	p := parser.New(lexer.New("fn twice(x) { 2x }"))
+	evaluator.Eval(env, p.Parse())
+	run(t, compile(t, "fn sq(x: float) -> float { x * x }"), env)
+
+	sq, _ := env.Get("sq")
+	if fn, ok := sq.(*object.Function); !ok || fn.Code == nil {
+		t.Fatalf("expected compiled function got %v", sq)
+	}
+	if twice, _ := env.Get("twice"); twice.(*object.Function).Code != nil {
+		t.Fatalf("function declared by evaluator is compiled")
+	}
+
+	testCases := []struct {
+		source   string
+		expected string
+	}{
+		// sq is declared by another program
+		{source: "sq(3)", expected: "9.000000"},
+		{source: "twice(sq(2))", expected: "8.000000"},
+		{source: "sq(twice(2))", expected: "16.000000"},
+		{source: "sq(true)", expected: "[ERROR] type error: sq: argument x: expected float got BOOL"},
+		{source: "sq(1, 2)", expected: "[ERROR] wrong arguments: sq: expected 1 arguments got 2"},
+		{source: "fn f(n) { { let m = n - 1\n m } }\nf(3)", expected: "2"},
+		{source: "fn f() { let a = 1 }\nf()", expected: "[ERROR] type error: f: no result, the last statement of body is not an expression"},
+		{source: "fn f(n) { f(n) }\nf(1)", expected: "[ERROR] recursion too deep: f: more than 1000 nested calls"},
+		{source: "fn f(n) { 1 / n\n n }\nf(0)", expected: "[ERROR] division by zero: 1 / 0"},
+		{source: "fn f(x) { x^2 }\ngrad(f, [3])", expected: "[6.000000]"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			if res := run(t, compile(t, tt.source), env.SubEnv()).Inspect(); res != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	testCases := []struct {
+		source   string
+		ctx      context.Context
+		limits   evaluator.Limits
+		expected string
+	}{
+		{source: "1 + 2 * 3", limits: evaluator.Limits{MaxSteps: 6}, expected: "7"},
+		{source: "1 + 2 * 3", limits: evaluator.Limits{MaxSteps: 5}, expected: "[ERROR] limit exceeded: more than 5 steps"},
+		{source: "1 + (2 + (3 + 4))", limits: evaluator.Limits{MaxDepth: 4}, expected: "[ERROR] limit exceeded: nesting deeper than 4"},
+		{source: "1 + 2 + 3 + 4", limits: evaluator.Limits{MaxDepth: 4}, expected: "10"},
+		{source: "1 + 1", ctx: cancelled, expected: "[ERROR] limit exceeded: context canceled"},
+		{source: "300000!\n1", limits: evaluator.Limits{MaxAlloc: 1000}, expected: "[ERROR] limit exceeded: 300000! is about 78525 words, maximum is 1000"},
+		{source: "let a = 1000!\nlet b = a * a\nlet c = b * b\nc * c\n1", limits: evaluator.Limits{MaxAlloc: 1000}, expected: "[ERROR] limit exceeded: integer of about 1068 words, maximum is 1000"},
+		{source: "2 ^ 100000\n1", limits: evaluator.Limits{MaxAlloc: 1000}, expected: "[ERROR] limit exceeded: integer of about 1563 words, maximum is 1000"},
+		// function bodies are evaluated within steps and depth left
+		{source: "fn f(x) { x + 1 }\nf(f(1))", limits: evaluator.Limits{MaxSteps: 10}, expected: "[ERROR] limit exceeded: more than 10 steps"},
+		{source: "fn f(x) { f(x) }\nf(1)", limits: evaluator.Limits{MaxDepth: 100}, expected: "[ERROR] limit exceeded: nesting deeper than 100"},
+		{source: "fn f(x) { x + 1 }\nf(1)", limits: evaluator.Limits{MaxSteps: 20}, expected: "2"},
+		// 6 instructions of program and 4 of compiled body
+		{source: "fn f(x) { x + 1 }\nf(1)", limits: evaluator.Limits{MaxSteps: 10}, expected: "2"},
+		{source: "fn f(x) { x + 1 }\nf(1)", limits: evaluator.Limits{MaxSteps: 9}, expected: "[ERROR] limit exceeded: more than 9 steps"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.source, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			machine := vm.New(compile(t, tt.source), object.NewEnv())
+			if err := machine.RunContext(ctx, tt.limits); err != nil {
+				t.Fatal(err)
+			}
+			if res := machine.Result().Inspect(); res != tt.expected {
+				t.Errorf("expected %s got %s", tt.expected, res)
+			}
+		})
+	}
+}